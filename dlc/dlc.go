@@ -0,0 +1,142 @@
+package dlc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/roasbeef/btcd/btcec"
+)
+
+// Oracle is what a contract needs to know about the data source it's
+// betting on: a long-term pubkey, plus the R-point it'll use for the
+// specific announcement this contract settles against.  Both are used at
+// settlement time: outcomePoint combines Pub and R into the commitment
+// point for each outcome message.
+type Oracle struct {
+	Pub [33]byte
+	R   [33]byte
+}
+
+// Contract is a single discreet log contract: a 2-of-2 funding output
+// whose payout splits between OurAmt and TheirAmt, settled the moment
+// either side learns the oracle's signature over the outcome message.
+// Persisted alongside the channel it rides next to, the same way a
+// Qchan's ChanStatic lives next to its elkrem state.
+type Contract struct {
+	PeerIdx uint32
+	CIdx    uint32
+
+	Oracle   Oracle
+	Maturity uint32 // block height the oracle is expected to sign by
+
+	FundingAmt int64
+	OurAmt     int64
+	TheirAmt   int64
+
+	FundingTxid [32]byte
+	FundingVout uint32
+}
+
+// ToBytes serializes a Contract for storage.  Fixed width throughout so
+// FromBytes doesn't need a length prefix anywhere.
+func (c Contract) ToBytes() []byte {
+	var buf bytes.Buffer
+	var b4 [4]byte
+	var b8 [8]byte
+
+	binary.BigEndian.PutUint32(b4[:], c.PeerIdx)
+	buf.Write(b4[:])
+	binary.BigEndian.PutUint32(b4[:], c.CIdx)
+	buf.Write(b4[:])
+
+	buf.Write(c.Oracle.Pub[:])
+	buf.Write(c.Oracle.R[:])
+
+	binary.BigEndian.PutUint32(b4[:], c.Maturity)
+	buf.Write(b4[:])
+
+	binary.BigEndian.PutUint64(b8[:], uint64(c.FundingAmt))
+	buf.Write(b8[:])
+	binary.BigEndian.PutUint64(b8[:], uint64(c.OurAmt))
+	buf.Write(b8[:])
+	binary.BigEndian.PutUint64(b8[:], uint64(c.TheirAmt))
+	buf.Write(b8[:])
+
+	buf.Write(c.FundingTxid[:])
+	binary.BigEndian.PutUint32(b4[:], c.FundingVout)
+	buf.Write(b4[:])
+
+	return buf.Bytes()
+}
+
+// FromBytes deserializes a Contract written by ToBytes.
+func FromBytes(b []byte) (Contract, error) {
+	var c Contract
+	if len(b) != 138 {
+		return c, fmt.Errorf("dlc Contract: need 138 bytes, got %d", len(b))
+	}
+	c.PeerIdx = binary.BigEndian.Uint32(b[0:4])
+	c.CIdx = binary.BigEndian.Uint32(b[4:8])
+	copy(c.Oracle.Pub[:], b[8:41])
+	copy(c.Oracle.R[:], b[41:74])
+	c.Maturity = binary.BigEndian.Uint32(b[74:78])
+	c.FundingAmt = int64(binary.BigEndian.Uint64(b[78:86]))
+	c.OurAmt = int64(binary.BigEndian.Uint64(b[86:94]))
+	c.TheirAmt = int64(binary.BigEndian.Uint64(b[94:102]))
+	copy(c.FundingTxid[:], b[102:134])
+	c.FundingVout = binary.BigEndian.Uint32(b[134:138])
+	return c, nil
+}
+
+// outcomePoint computes the oracle's commitment point for message m under
+// the standard scheme: P = R + H(R || m)*OraclePub.  Whoever later learns
+// the oracle's signature over m learns the discrete log of P relative to
+// the generator, i.e. the privkey that P is a pubkey for.
+func outcomePoint(oracle Oracle, msg []byte) (*btcec.PublicKey, error) {
+	oraclePub, err := btcec.ParsePubKey(oracle.Pub[:], btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	r, err := btcec.ParsePubKey(oracle.R[:], btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(oracle.R[:])
+	h.Write(msg)
+	e := new(big.Int).SetBytes(h.Sum(nil))
+
+	ex, ey := btcec.S256().ScalarMult(oraclePub.X, oraclePub.Y, e.Bytes())
+	px, py := btcec.S256().Add(r.X, r.Y, ex, ey)
+
+	return &btcec.PublicKey{Curve: btcec.S256(), X: px, Y: py}, nil
+}
+
+// SettlementPrivKey reconstructs the privkey that spends our side of a
+// settled contract, once the oracle has actually published its signature
+// scalar oracleSig over the outcome message.  ourBase is our own privkey
+// derived from UseContractPayoutBase; the two get added mod N the same
+// way sorceror combines a revocation base with an elkrem hash.
+func SettlementPrivKey(oracleSig [32]byte, ourBase *btcec.PrivateKey) *btcec.PrivateKey {
+	s := new(big.Int).SetBytes(oracleSig[:])
+	k := new(big.Int).Add(s, ourBase.D)
+	k.Mod(k, btcec.S256().N)
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), k.Bytes())
+	return priv
+}
+
+// SettlementPubKey is the pubkey side of SettlementPrivKey, computable by
+// either party before the oracle ever signs anything: it's what actually
+// gets baked into the funding output's payout script.
+func SettlementPubKey(oracle Oracle, msg []byte, ourBasePub *btcec.PublicKey) (*btcec.PublicKey, error) {
+	op, err := outcomePoint(oracle, msg)
+	if err != nil {
+		return nil, err
+	}
+	x, y := btcec.S256().Add(op.X, op.Y, ourBasePub.X, ourBasePub.Y)
+	return &btcec.PublicKey{Curve: btcec.S256(), X: x, Y: y}, nil
+}
@@ -0,0 +1,45 @@
+package dlc
+
+import "testing"
+
+func TestContractBytesRoundTrip(t *testing.T) {
+	c := Contract{
+		PeerIdx:     1,
+		CIdx:        2,
+		Maturity:    500000,
+		FundingAmt:  100000,
+		OurAmt:      60000,
+		TheirAmt:    40000,
+		FundingVout: 1,
+	}
+	for i := range c.Oracle.Pub {
+		c.Oracle.Pub[i] = byte(i)
+	}
+	for i := range c.Oracle.R {
+		c.Oracle.R[i] = byte(i + 1)
+	}
+	for i := range c.FundingTxid {
+		c.FundingTxid[i] = byte(i + 2)
+	}
+
+	b := c.ToBytes()
+	if len(b) != 138 {
+		t.Fatalf("ToBytes: expected 138 bytes, got %d", len(b))
+	}
+
+	back, err := FromBytes(b)
+	if err != nil {
+		t.Fatalf("FromBytes: %s", err.Error())
+	}
+	if back != c {
+		t.Fatalf("round trip mismatch:\nwant %+v\ngot  %+v", c, back)
+	}
+}
+
+func TestContractFromBytesBadLength(t *testing.T) {
+	for _, n := range []int{0, 137, 139} {
+		if _, err := FromBytes(make([]byte, n)); err == nil {
+			t.Fatalf("FromBytes: expected error for %d-byte input, got nil", n)
+		}
+	}
+}
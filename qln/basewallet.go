@@ -41,6 +41,28 @@ type UWallet interface {
 	// NahDontSend cancels the MaybeSend transaction.
 	NahDontSend(txid *wire.ShaHash) error
 
+	// FreezeContribution earmarks amt sats of this wallet's own utxos
+	// towards a transaction somebody else is assembling, the same way
+	// MaybeSend freezes inputs, but without adding any output of its
+	// own; the caller adds the shared output once every party's inputs
+	// are in. Needed for collaborative (dual-funded) transactions where
+	// only one side builds the final output list.
+	FreezeContribution(amt int64) ([]*wire.TxIn, error)
+
+	// UnfreezeContribution releases inputs previously frozen by
+	// FreezeContribution, the same way NahDontSend releases a MaybeSend.
+	// Callers must use this on every error path after FreezeContribution
+	// succeeds but before the frozen inputs are actually broadcast in a
+	// signed tx, or they leak: nothing else ever gives them back.
+	UnfreezeContribution(ins []*wire.TxIn) error
+
+	// SignMyInputs signs, in place, whichever of tx's inputs spend utxos
+	// this wallet froze via MaybeSend or FreezeContribution, using
+	// SIGHASH_ANYONECANPAY so the signature survives other parties'
+	// inputs being appended to tx afterward. Every other input is left
+	// untouched.
+	SignMyInputs(tx *wire.MsgTx) error
+
 	// Ask for network parameters
 	Params() *chaincfg.Params
 }
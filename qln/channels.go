@@ -0,0 +1,59 @@
+package qln
+
+import (
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnutil"
+)
+
+// ChannelSummary is the read-only subset of Qchan state ListChannels
+// reports: enough for a caller to identify a channel and see roughly what
+// shape it's in, without handing out the whole state machine.
+type ChannelSummary struct {
+	PeerIdx    uint32
+	ChanIdx    uint32
+	Capacity   int64
+	StateDelta int64
+	Closed     bool
+}
+
+// ListChannels walks every peer this node has ever talked to and, for
+// each, every channel index up to the first one GetQchanByIdx can't find
+// (the same "walk cIdx from zero until it errors" pattern
+// resumeAllWithPeer uses to find in-flight channels to resume).
+func (nd *LnNode) ListChannels() ([]ChannelSummary, error) {
+	var peerIdxs []uint32
+	err := nd.LnDB.View(func(btx *bolt.Tx) error {
+		bkt := btx.Bucket(BKTPeers)
+		if bkt == nil {
+			return nil // nobody's ever connected; nothing to list
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			if v != nil {
+				return nil // not a sub-bucket, skip
+			}
+			peerIdxs = append(peerIdxs, lnutil.BtU32(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var out []ChannelSummary
+	for _, peerIdx := range peerIdxs {
+		for cIdx := uint32(0); ; cIdx++ {
+			qc, err := nd.GetQchanByIdx(peerIdx, cIdx)
+			if err != nil {
+				break // ran off the end of this peer's channels
+			}
+			out = append(out, ChannelSummary{
+				PeerIdx:    peerIdx,
+				ChanIdx:    cIdx,
+				Capacity:   qc.Value,
+				StateDelta: qc.State.Delta,
+				Closed:     qc.CloseData.Closed,
+			})
+		}
+	}
+	return out, nil
+}
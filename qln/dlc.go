@@ -0,0 +1,679 @@
+package qln
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/dlc"
+	"github.com/lightningnetwork/lnd/lnutil"
+	"github.com/lightningnetwork/lnd/portxo"
+
+	"github.com/boltdb/bolt"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// DLC message IDs, analogous to the channel-open handshake
+// (MSGID_POINTREQ et al) and the dualfund handshake in dualfund.go.
+const (
+	MSGID_DLCOFFER  = 0x40 // initiator -> responder, propose a contract
+	MSGID_DLCACCEPT = 0x41 // responder -> initiator, their pubkeys + CET sigs + funding tx
+	MSGID_DLCSIG    = 0x42 // initiator -> responder, our CET sigs
+)
+
+// dlcOfferFixedLen is everything in a MSGID_DLCOFFER before the
+// variable-length serialized partial funding tx: msgid(1) + fundPub(33) +
+// payoutBasePub(33) + oracle.Pub(33) + oracle.R(33) + ourAmt(8) +
+// theirAmt(8).
+const dlcOfferFixedLen = 1 + 33 + 33 + 33 + 33 + 8 + 8
+
+// dlcAcceptFixedLen is everything in a MSGID_DLCACCEPT before the CET
+// sig blob: msgid(1) + fundPub(33) + payoutBasePub(33).
+const dlcAcceptFixedLen = 1 + 33 + 33
+
+// cetOutcomes are the two messages the oracle can sign at maturity.
+// This is a winner-take-all curve: cetOutcomes[0] pays the whole
+// contract to the responder, cetOutcomes[1] to the initiator.
+var cetOutcomes = [2][]byte{[]byte("0"), []byte("1")}
+
+// BKTContracts holds one sub-bucket per DLC, keyed by peerIdx||cIdx the
+// same way a channel would be, so a contract rides next to whatever
+// channel-ish state the two peers already share.
+var BKTContracts = []byte("dlc")
+
+var (
+	KEYContract  = []byte("con")  // dlc.Contract.ToBytes()
+	KEYPeerInfo  = []byte("peer") // dlcPeerInfo.toBytes()
+	KEYTheirCETs = []byte("cet")  // their CET sigs, encodeCETSigs'd
+)
+
+// contractKey is the bucket key for a given peer/contract pair.
+func contractKey(peerIdx, cIdx uint32) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint32(k[:4], peerIdx)
+	binary.BigEndian.PutUint32(k[4:], cIdx)
+	return k
+}
+
+// dlcPeerInfo is the handshake state qln itself needs to keep about a
+// contract, kept separate from dlc.Contract because Contract's wire
+// format is fixed-width (138 bytes) and shared with the dlc package's
+// own tests; none of this belongs in there.
+type dlcPeerInfo struct {
+	TheirFundPub   [33]byte
+	TheirPayoutPub [33]byte
+	Responder      bool // true if we're the side that replied to an offer
+}
+
+// toBytes serializes a dlcPeerInfo to its fixed 67-byte form.
+func (pi dlcPeerInfo) toBytes() []byte {
+	b := make([]byte, 67)
+	copy(b[:33], pi.TheirFundPub[:])
+	copy(b[33:66], pi.TheirPayoutPub[:])
+	if pi.Responder {
+		b[66] = 1
+	}
+	return b
+}
+
+// dlcPeerInfoFromBytes deserializes a dlcPeerInfo from its 67-byte form.
+func dlcPeerInfoFromBytes(b []byte) (dlcPeerInfo, error) {
+	var pi dlcPeerInfo
+	if len(b) != 67 {
+		return pi, fmt.Errorf("dlcPeerInfo: need 67 bytes, got %d", len(b))
+	}
+	copy(pi.TheirFundPub[:], b[:33])
+	copy(pi.TheirPayoutPub[:], b[33:66])
+	pi.Responder = b[66] == 1
+	return pi, nil
+}
+
+// encodeCETSigs serializes a list of CET signatures as a 1-byte length
+// prefix per entry.  Unlike sorceror's fixed 64-byte raw R‖S, these are
+// DER-encoded plus a trailing sighash-type byte, so they aren't fixed
+// width and need a real length prefix instead of fixed chunking.
+func encodeCETSigs(sigs [][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, sig := range sigs {
+		if len(sig) > 255 {
+			return nil, fmt.Errorf("encodeCETSigs: sig too long (%d bytes)", len(sig))
+		}
+		buf.WriteByte(byte(len(sig)))
+		buf.Write(sig)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCETSigs reads n length-prefixed signatures off the front of b,
+// returning them along with whatever's left over.
+func decodeCETSigs(b []byte, n int) ([][]byte, []byte, error) {
+	sigs := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		if len(b) < 1 {
+			return nil, nil, fmt.Errorf("decodeCETSigs: ran out of bytes for sig %d", i)
+		}
+		l := int(b[0])
+		b = b[1:]
+		if len(b) < l {
+			return nil, nil, fmt.Errorf("decodeCETSigs: ran out of bytes for sig %d", i)
+		}
+		sigs = append(sigs, b[:l])
+		b = b[l:]
+	}
+	return sigs, b, nil
+}
+
+// saveContract persists a Contract, the handshake info we need to
+// rebuild its CETs later, and whichever CET signatures we've collected
+// from the counterparty so far.
+func (nd *LnNode) saveContract(c dlc.Contract, pi dlcPeerInfo, theirCETSigs [][]byte) error {
+	sigBlob, err := encodeCETSigs(theirCETSigs)
+	if err != nil {
+		return err
+	}
+	return nd.LnDB.Update(func(btx *bolt.Tx) error {
+		bkt, err := btx.CreateBucketIfNotExists(BKTContracts)
+		if err != nil {
+			return err
+		}
+		cbkt, err := bkt.CreateBucketIfNotExists(contractKey(c.PeerIdx, c.CIdx))
+		if err != nil {
+			return err
+		}
+		if err := cbkt.Put(KEYContract, c.ToBytes()); err != nil {
+			return err
+		}
+		if err := cbkt.Put(KEYPeerInfo, pi.toBytes()); err != nil {
+			return err
+		}
+		return cbkt.Put(KEYTheirCETs, sigBlob)
+	})
+}
+
+// loadContract reads back a Contract, its peer info, and the
+// counterparty's CET sigs (if the handshake got that far).
+func (nd *LnNode) loadContract(peerIdx, cIdx uint32) (dlc.Contract, dlcPeerInfo, [][]byte, error) {
+	var c dlc.Contract
+	var pi dlcPeerInfo
+	var sigs [][]byte
+	err := nd.LnDB.View(func(btx *bolt.Tx) error {
+		bkt := btx.Bucket(BKTContracts)
+		if bkt == nil {
+			return fmt.Errorf("no dlc bucket")
+		}
+		cbkt := bkt.Bucket(contractKey(peerIdx, cIdx))
+		if cbkt == nil {
+			return fmt.Errorf("no contract %d,%d", peerIdx, cIdx)
+		}
+		raw := cbkt.Get(KEYContract)
+		if raw == nil {
+			return fmt.Errorf("contract %d,%d has no data", peerIdx, cIdx)
+		}
+		var err error
+		c, err = dlc.FromBytes(raw)
+		if err != nil {
+			return err
+		}
+		piRaw := cbkt.Get(KEYPeerInfo)
+		if piRaw == nil {
+			return fmt.Errorf("contract %d,%d has no peer info", peerIdx, cIdx)
+		}
+		pi, err = dlcPeerInfoFromBytes(piRaw)
+		if err != nil {
+			return err
+		}
+		if sigBlob := cbkt.Get(KEYTheirCETs); len(sigBlob) > 0 {
+			sigs, _, err = decodeCETSigs(sigBlob, len(cetOutcomes))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return c, pi, sigs, err
+}
+
+// DLCInProg holds the state of a DLC handshake that hasn't finished yet,
+// the dlc.go equivalent of DualFundInProg.  It lives on LNode.InProg
+// alongside Dual so there's still only one DLC handshake in flight with
+// a given peer at a time, and so DLCAcceptHandler/DLCSigHandler/
+// latestContractIdx can find the right contract index instead of
+// guessing 0.
+type DLCInProg struct {
+	PeerIdx uint32
+	CIdx    uint32
+
+	// Contract is the proposed terms; FundingTxid/FundingVout get filled
+	// in once the joint funding tx is fully assembled.
+	Contract dlc.Contract
+
+	OurFundPub   [33]byte
+	OurPayoutPub [33]byte
+
+	Responder bool // true if we're the side replying to an offer
+}
+
+// channelFundKeyGen's contract equivalent: portxoKeyGenFor/portxoKeyGenForUse
+// build the bip32 path for a contract's keys, peer and contract index
+// pinning Step[3]/Step[4], "use" pinning Step[2] (UseContractFundMultisig
+// or UseContractPayoutBase).
+func portxoKeyGenFor(peerIdx, cIdx uint32) (k portxo.KeyGen) {
+	return portxoKeyGenForUse(peerIdx, cIdx, UseContractFundMultisig)
+}
+
+func portxoKeyGenForUse(peerIdx, cIdx, use uint32) (k portxo.KeyGen) {
+	k.Depth = 5
+	k.Step[0] = 44 | 1<<31
+	k.Step[1] = 0 | 1<<31
+	k.Step[2] = use
+	k.Step[3] = peerIdx
+	k.Step[4] = cIdx
+	return k
+}
+
+// latestContractIdx finds which contract index an in-flight DLC
+// handshake with peerIdx refers to, tracked on InProg.DLC the same way
+// dualfund tracks InProg.Dual.  A peer's second-or-later contract no
+// longer clobbers the first's handshake state the way a hardcoded 0 did.
+func (nd *LnNode) latestContractIdx(peerIdx uint32) (uint32, error) {
+	if nd.InProg.DLC == nil || nd.InProg.DLC.PeerIdx != peerIdx {
+		return 0, fmt.Errorf("no dlc handshake in progress with peer %d", peerIdx)
+	}
+	return nd.InProg.DLC.CIdx, nil
+}
+
+// DLCOffer proposes a new contract to the connected peer, freezing our
+// half of the 2-of-2 via UWallet.FreezeContribution the same way
+// DualFundChannel freezes a dual-funded channel's local side.  ourAmt /
+// theirAmt set up a winner-take-all payout: whoever DLCSettle finds the
+// oracle sided with gets the whole FundingAmt.
+func (nd *LnNode) DLCOffer(oracle dlc.Oracle, ourAmt, theirAmt int64) error {
+	if nd.RemoteCon == nil || nd.RemoteCon.RemotePub == nil {
+		return fmt.Errorf("not connected to anyone")
+	}
+	if nd.InProg.DLC != nil {
+		return fmt.Errorf("dlc with peer %d not done yet", nd.InProg.DLC.PeerIdx)
+	}
+
+	var peerArr [33]byte
+	copy(peerArr[:], nd.RemoteCon.RemotePub.SerializeCompressed())
+	peerIdx, cIdx, err := nd.NextIdxForPeer(peerArr)
+	if err != nil {
+		return err
+	}
+	if err := nd.RegisterPeer(peerIdx); err != nil {
+		return err
+	}
+
+	ourIns, err := nd.BaseWallet.FreezeContribution(ourAmt)
+	if err != nil {
+		return err
+	}
+
+	fundPub := nd.GetUsePub(portxoKeyGenFor(peerIdx, cIdx), UseContractFundMultisig)
+	payoutBasePub := nd.GetUsePub(portxoKeyGenForUse(peerIdx, cIdx, UseContractPayoutBase), UseContractPayoutBase)
+
+	nd.InProg.DLC = &DLCInProg{
+		PeerIdx: peerIdx,
+		CIdx:    cIdx,
+		Contract: dlc.Contract{
+			PeerIdx:    peerIdx,
+			CIdx:       cIdx,
+			Oracle:     oracle,
+			FundingAmt: ourAmt + theirAmt,
+			OurAmt:     ourAmt,
+			TheirAmt:   theirAmt,
+		},
+		OurFundPub:   fundPub,
+		OurPayoutPub: payoutBasePub,
+	}
+
+	tx := wire.NewMsgTx()
+	for _, in := range ourIns {
+		tx.AddTxIn(in)
+	}
+	var txBuf bytes.Buffer
+	if err := tx.Serialize(&txBuf); err != nil {
+		return err
+	}
+
+	msg := []byte{MSGID_DLCOFFER}
+	msg = append(msg, fundPub[:]...)
+	msg = append(msg, payoutBasePub[:]...)
+	msg = append(msg, oracle.Pub[:]...)
+	msg = append(msg, oracle.R[:]...)
+	msg = append(msg, lnutil.I64tB(ourAmt)...)
+	msg = append(msg, lnutil.I64tB(theirAmt)...)
+	msg = append(msg, txBuf.Bytes()...)
+
+	_, err = nd.RemoteCon.Write(msg)
+	return err
+}
+
+// DLCOfferHandler runs on the responder side.  It folds its own inputs
+// and the real 2-of-2 funding output into the initiator's partial tx,
+// signs its side, builds and signs both outcomes' CETs, and replies with
+// its pubkeys, CET sigs, and the now fully-assembled (but not yet fully
+// signed) funding tx.
+func (nd *LnNode) DLCOfferHandler(msg []byte, peerArr [33]byte) error {
+	if nd.InProg.DLC != nil {
+		return fmt.Errorf("DLCOfferHandler: dlc with peer already pending")
+	}
+	if len(msg) < dlcOfferFixedLen {
+		return fmt.Errorf("DLCOfferHandler: bad message length %d", len(msg))
+	}
+
+	var theirFundPub, theirPayoutPub [33]byte
+	copy(theirFundPub[:], msg[1:34])
+	copy(theirPayoutPub[:], msg[34:67])
+	var oracle dlc.Oracle
+	copy(oracle.Pub[:], msg[67:100])
+	copy(oracle.R[:], msg[100:133])
+	theirAmt := lnutil.BtI64(msg[133:141]) // what they offered to put in
+	ourAmt := lnutil.BtI64(msg[141:149])   // what they're asking us to put in
+
+	tx := wire.NewMsgTx()
+	if err := tx.Deserialize(bytes.NewReader(msg[dlcOfferFixedLen:])); err != nil {
+		return fmt.Errorf("DLCOfferHandler: bad funding tx: %s", err.Error())
+	}
+
+	peerIdx, cIdx, err := nd.NextIdxForPeer(peerArr)
+	if err != nil {
+		return err
+	}
+	if err := nd.RegisterPeer(peerIdx); err != nil {
+		return err
+	}
+
+	ourIns, err := nd.BaseWallet.FreezeContribution(ourAmt)
+	if err != nil {
+		return err
+	}
+
+	fundPub := nd.GetUsePub(portxoKeyGenFor(peerIdx, cIdx), UseContractFundMultisig)
+	payoutBasePub := nd.GetUsePub(portxoKeyGenForUse(peerIdx, cIdx, UseContractPayoutBase), UseContractPayoutBase)
+
+	for _, in := range ourIns {
+		tx.AddTxIn(in)
+	}
+	tx.AddTxOut(twoOfTwoFundingOutput(theirFundPub, fundPub, ourAmt+theirAmt))
+
+	if err := nd.BaseWallet.SignMyInputs(tx); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(ourIns) // already returning the real error below
+		return err
+	}
+
+	c := dlc.Contract{
+		PeerIdx:     peerIdx,
+		CIdx:        cIdx,
+		Oracle:      oracle,
+		FundingAmt:  ourAmt + theirAmt,
+		OurAmt:      ourAmt,
+		TheirAmt:    theirAmt,
+		FundingTxid: [32]byte(tx.TxSha()),
+		FundingVout: 0,
+	}
+
+	// we're the responder, so we win outcome 0; they win outcome 1.
+	ourSigs, err := nd.signCETs(c, fundPub, theirFundPub, theirPayoutPub, payoutBasePub)
+	if err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(ourIns) // already returning the real error below
+		return err
+	}
+
+	pi := dlcPeerInfo{
+		TheirFundPub:   theirFundPub,
+		TheirPayoutPub: theirPayoutPub,
+		Responder:      true,
+	}
+	if err := nd.saveContract(c, pi, nil); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(ourIns) // already returning the real error below
+		return err
+	}
+
+	nd.InProg.DLC = &DLCInProg{
+		PeerIdx:      peerIdx,
+		CIdx:         cIdx,
+		Contract:     c,
+		OurFundPub:   fundPub,
+		OurPayoutPub: payoutBasePub,
+		Responder:    true,
+	}
+
+	var txBuf bytes.Buffer
+	if err := tx.Serialize(&txBuf); err != nil {
+		return err
+	}
+	sigBlob, err := encodeCETSigs(ourSigs)
+	if err != nil {
+		return err
+	}
+
+	out := []byte{MSGID_DLCACCEPT}
+	out = append(out, fundPub[:]...)
+	out = append(out, payoutBasePub[:]...)
+	out = append(out, sigBlob...)
+	out = append(out, txBuf.Bytes()...)
+	_, err = nd.RemoteCon.Write(out)
+	return err
+}
+
+// DLCAcceptHandler runs on the initiator side: it records the
+// responder's pubkeys and CET signatures, signs its own side of the
+// joint funding tx, broadcasts it, signs its own CETs, and sends those
+// signatures back.
+func (nd *LnNode) DLCAcceptHandler(msg []byte, peerIdx uint32) error {
+	if _, err := nd.latestContractIdx(peerIdx); err != nil {
+		return err
+	}
+	if nd.InProg.DLC.Responder {
+		return fmt.Errorf("DLCAcceptHandler: no matching dlc offer in progress")
+	}
+	if len(msg) < dlcAcceptFixedLen {
+		return fmt.Errorf("DLCAcceptHandler: bad message length %d", len(msg))
+	}
+	prog := nd.InProg.DLC
+
+	var theirFundPub, theirPayoutPub [33]byte
+	copy(theirFundPub[:], msg[1:34])
+	copy(theirPayoutPub[:], msg[34:67])
+
+	theirSigs, rest, err := decodeCETSigs(msg[dlcAcceptFixedLen:], len(cetOutcomes))
+	if err != nil {
+		nd.InProg.DLC = nil
+		return fmt.Errorf("DLCAcceptHandler: %s", err.Error())
+	}
+
+	tx := wire.NewMsgTx()
+	if err := tx.Deserialize(bytes.NewReader(rest)); err != nil {
+		nd.InProg.DLC = nil
+		return fmt.Errorf("DLCAcceptHandler: bad funding tx: %s", err.Error())
+	}
+
+	c := prog.Contract
+	wantOut := twoOfTwoFundingOutput(prog.OurFundPub, theirFundPub, c.FundingAmt)
+	if len(tx.TxOut) != 1 || tx.TxOut[0].Value != wantOut.Value ||
+		!bytes.Equal(tx.TxOut[0].PkScript, wantOut.PkScript) {
+		nd.InProg.DLC = nil
+		return fmt.Errorf("DLCAcceptHandler: funding tx doesn't pay our 2-of-2")
+	}
+	c.FundingTxid = [32]byte(tx.TxSha())
+	c.FundingVout = 0
+
+	// we're the initiator, so we win outcome 1; they win outcome 0.
+	ourSigs, err := nd.signCETs(c, prog.OurFundPub, theirFundPub, prog.OurPayoutPub, theirPayoutPub)
+	if err != nil {
+		nd.InProg.DLC = nil
+		return err
+	}
+
+	if err := nd.BaseWallet.SignMyInputs(tx); err != nil {
+		nd.InProg.DLC = nil
+		return err
+	}
+	if err := nd.BaseWallet.PushTx(tx); err != nil {
+		nd.InProg.DLC = nil
+		return err
+	}
+
+	pi := dlcPeerInfo{
+		TheirFundPub:   theirFundPub,
+		TheirPayoutPub: theirPayoutPub,
+		Responder:      false,
+	}
+	if err := nd.saveContract(c, pi, theirSigs); err != nil {
+		nd.InProg.DLC = nil
+		return err
+	}
+
+	sigBlob, err := encodeCETSigs(ourSigs)
+	nd.InProg.DLC = nil
+	if err != nil {
+		return err
+	}
+
+	out := append([]byte{MSGID_DLCSIG}, sigBlob...)
+	_, err = nd.RemoteCon.Write(out)
+	return err
+}
+
+// DLCSigHandler runs on the responder side: it's the last message of the
+// handshake, handing over the initiator's CET signatures so the contract
+// can be settled later without any further cooperation.
+func (nd *LnNode) DLCSigHandler(msg []byte, peerIdx uint32) error {
+	cIdx, err := nd.latestContractIdx(peerIdx)
+	if err != nil {
+		return err
+	}
+	if !nd.InProg.DLC.Responder {
+		return fmt.Errorf("DLCSigHandler: no matching dlc offer in progress")
+	}
+	nd.InProg.DLC = nil
+
+	theirSigs, _, err := decodeCETSigs(msg[1:], len(cetOutcomes))
+	if err != nil {
+		return fmt.Errorf("DLCSigHandler: %s", err.Error())
+	}
+
+	c, pi, _, err := nd.loadContract(peerIdx, cIdx)
+	if err != nil {
+		return err
+	}
+	return nd.saveContract(c, pi, theirSigs)
+}
+
+// cetPayoutScript builds the bare P2PK output script a CET pays its
+// winner with.  SettlementPubKey combines the oracle's outcome point
+// with winnerBasePub, so only whoever holds winnerBasePub's privkey can
+// ever produce the matching settlement privkey, and only once the
+// oracle actually signs this particular outcome.
+func cetPayoutScript(oracle dlc.Oracle, outcome []byte, winnerBasePub [33]byte) ([]byte, error) {
+	basePub, err := btcec.ParsePubKey(winnerBasePub[:], btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	settlePub, err := dlc.SettlementPubKey(oracle, outcome, basePub)
+	if err != nil {
+		return nil, err
+	}
+	b := txscript.NewScriptBuilder()
+	b.AddData(settlePub.SerializeCompressed())
+	b.AddOp(txscript.OP_CHECKSIG)
+	return b.Script()
+}
+
+// buildCET builds the unsigned contract execution tx for a single
+// outcome: it spends the funding output straight to whichever pubkey
+// cetPayoutScript derives for outcome/winnerBasePub, the whole
+// contract's value in one shot since this is a winner-take-all curve.
+func buildCET(c dlc.Contract, outcome []byte, winnerBasePub [33]byte) (*wire.MsgTx, error) {
+	script, err := cetPayoutScript(c.Oracle, outcome, winnerBasePub)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx()
+	tx.Version = 1
+	var txid wire.ShaHash
+	copy(txid[:], c.FundingTxid[:])
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txid, c.FundingVout), nil))
+	tx.AddTxOut(wire.NewTxOut(c.FundingAmt, script))
+	return tx, nil
+}
+
+// signCETs builds this node's half of the 2-of-2 signature on both of
+// c's CETs - one per cetOutcomes entry - over the real BIP143 witness
+// sighash of the funding output's redeem script, the same pattern
+// sorceror's sigVerifies uses to check a revocation-path signature.
+func (nd *LnNode) signCETs(c dlc.Contract, ourFundPub, theirFundPub, initiatorPayoutPub, responderPayoutPub [33]byte) ([][]byte, error) {
+	ourFundPriv := nd.GetPriv(portxoKeyGenFor(c.PeerIdx, c.CIdx))
+	redeem := twoOfTwoRedeemScript(ourFundPub, theirFundPub)
+
+	sigs := make([][]byte, len(cetOutcomes))
+	for i, outcome := range cetOutcomes {
+		winnerPub := responderPayoutPub
+		if i == 1 {
+			winnerPub = initiatorPayoutPub
+		}
+		tx, err := buildCET(c, outcome, winnerPub)
+		if err != nil {
+			return nil, err
+		}
+		sigHashes := txscript.NewTxSigHashes(tx)
+		sigHash, err := txscript.CalcWitnessSigHash(
+			redeem, sigHashes, txscript.SigHashAll, tx, 0, c.FundingAmt)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := ourFundPriv.Sign(sigHash)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = append(sig.Serialize(), byte(txscript.SigHashAll))
+	}
+	return sigs, nil
+}
+
+// cetWitness assembles the witness stack for spending a DLC funding
+// output's 2-of-2 script: CHECKMULTISIG's off-by-one dummy element, then
+// both sigs in the same order twoOfTwoRedeemScript sorted their pubkeys
+// in, then the redeem script itself.
+func cetWitness(ourFundPub, theirFundPub [33]byte, ourSig, theirSig, redeem []byte) [][]byte {
+	first, _ := sortFundPubs(ourFundPub, theirFundPub)
+	sigA, sigB := ourSig, theirSig
+	if !bytes.Equal(first[:], ourFundPub[:]) {
+		sigA, sigB = theirSig, ourSig
+	}
+	return [][]byte{{}, sigA, sigB, redeem}
+}
+
+// DLCSettle finalizes contractIdx once the oracle has actually signed an
+// outcome.  It figures out which outcome that was by checking which of
+// the two candidate SettlementPubKeys oracleSig's implied privkey
+// matches, confirms that outcome is the one where we win, and broadcasts
+// the winning CET with a real 2-of-2 witness.
+func (nd *LnNode) DLCSettle(peerIdx, cIdx uint32, oracleSig [32]byte) error {
+	c, pi, theirSigs, err := nd.loadContract(peerIdx, cIdx)
+	if err != nil {
+		return err
+	}
+	if len(theirSigs) != len(cetOutcomes) {
+		return fmt.Errorf("contract %d,%d never finished its handshake", peerIdx, cIdx)
+	}
+
+	ourFundPub := nd.GetUsePub(portxoKeyGenFor(peerIdx, cIdx), UseContractFundMultisig)
+	payoutBasePriv := nd.GetPriv(portxoKeyGenForUse(peerIdx, cIdx, UseContractPayoutBase))
+	settlePriv := dlc.SettlementPrivKey(oracleSig, payoutBasePriv)
+	settlePub := settlePriv.PubKey()
+	ourPayoutPub := payoutBasePriv.PubKey()
+
+	outcomeIdx := -1
+	for i, outcome := range cetOutcomes {
+		candidate, err := dlc.SettlementPubKey(c.Oracle, outcome, ourPayoutPub)
+		if err != nil {
+			return err
+		}
+		if candidate.X.Cmp(settlePub.X) == 0 && candidate.Y.Cmp(settlePub.Y) == 0 {
+			outcomeIdx = i
+			break
+		}
+	}
+	if outcomeIdx < 0 {
+		return fmt.Errorf("oracle signature doesn't match either outcome of contract %d,%d", peerIdx, cIdx)
+	}
+
+	weWin := outcomeIdx == 1
+	if pi.Responder {
+		weWin = outcomeIdx == 0
+	}
+	if !weWin {
+		return fmt.Errorf("oracle signed the outcome where the counterparty wins; nothing to settle")
+	}
+
+	var ourPayoutPubArr [33]byte
+	copy(ourPayoutPubArr[:], ourPayoutPub.SerializeCompressed())
+
+	tx, err := buildCET(c, cetOutcomes[outcomeIdx], ourPayoutPubArr)
+	if err != nil {
+		return err
+	}
+
+	redeem := twoOfTwoRedeemScript(ourFundPub, pi.TheirFundPub)
+	sigHashes := txscript.NewTxSigHashes(tx)
+	sigHash, err := txscript.CalcWitnessSigHash(
+		redeem, sigHashes, txscript.SigHashAll, tx, 0, c.FundingAmt)
+	if err != nil {
+		return err
+	}
+	ourFundPriv := nd.GetPriv(portxoKeyGenFor(peerIdx, cIdx))
+	ourSig, err := ourFundPriv.Sign(sigHash)
+	if err != nil {
+		return err
+	}
+	ourDERSig := append(ourSig.Serialize(), byte(txscript.SigHashAll))
+
+	tx.TxIn[0].Witness = cetWitness(ourFundPub, pi.TheirFundPub, ourDERSig, theirSigs[outcomeIdx], redeem)
+
+	return nd.BaseWallet.PushTx(tx)
+}
@@ -0,0 +1,40 @@
+package qln
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestCetWitnessSigOrder makes sure cetWitness places each side's CET
+// signature in the same slot its pubkey occupies in the 2-of-2 redeem
+// script (sortFundPubs order), regardless of whether "our" pubkey happens
+// to be the lower or higher of the two.
+func TestCetWitnessSigOrder(t *testing.T) {
+	var lo, hi [33]byte
+	lo[0] = 0x02
+	hi[0] = 0x03
+	ourSig := []byte{0xaa}
+	theirSig := []byte{0xbb}
+	redeem := []byte{0xcc}
+
+	w := cetWitness(lo, hi, ourSig, theirSig, redeem)
+	if len(w) != 4 {
+		t.Fatalf("cetWitness: expected 4 witness items, got %d", len(w))
+	}
+	if !bytes.Equal(w[0], []byte{}) {
+		t.Fatalf("cetWitness: expected empty OP_CHECKMULTISIG dummy, got %x", w[0])
+	}
+	if !bytes.Equal(w[1], ourSig) || !bytes.Equal(w[2], theirSig) {
+		t.Fatalf("cetWitness(lo, hi): want sig order (our, their), got (%x, %x)", w[1], w[2])
+	}
+	if !bytes.Equal(w[3], redeem) {
+		t.Fatalf("cetWitness: expected redeem script last, got %x", w[3])
+	}
+
+	// now our pubkey is the higher of the two - our sig should move to
+	// the second slot to keep matching its pubkey's script position.
+	w = cetWitness(hi, lo, ourSig, theirSig, redeem)
+	if !bytes.Equal(w[1], theirSig) || !bytes.Equal(w[2], ourSig) {
+		t.Fatalf("cetWitness(hi, lo): want sig order (their, our), got (%x, %x)", w[1], w[2])
+	}
+}
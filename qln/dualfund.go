@@ -0,0 +1,430 @@
+package qln
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/lightningnetwork/lnd/lnutil"
+	"github.com/lightningnetwork/lnd/portxo"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// Dual-fund message IDs.  These sit in the same space as the rest of the
+// channel establishment messages (MSGID_POINTREQ, MSGID_POINTRESP, ...)
+// but are only sent once both sides have opted into a dual-funded open.
+const (
+	MSGID_DUALFUND_REQ     = 0x30 // initiator -> responder, propose dual-fund
+	MSGID_DUALFUND_ACCEPT  = 0x31 // responder -> initiator, its fund pubkey + partial tx
+	MSGID_DUALFUND_DECLINE = 0x32 // responder -> initiator, reason byte follows
+	MSGID_DUALFUND_SIGS    = 0x33 // initiator -> responder, the fully-signed funding tx
+)
+
+// dualFundReqFixedLen is everything in a MSGID_DUALFUND_REQ before the
+// variable-length serialized partial tx: msgid(1) + coinType(4) +
+// localAmt(8) + remoteAmt(8) + initialPush(8) + fundPub(33).
+const dualFundReqFixedLen = 1 + 4 + 8 + 8 + 8 + 33
+
+// Dual-fund decline reasons.  Sent as the single byte following
+// MSGID_DUALFUND_DECLINE so the initiator can tell the user why the
+// responder backed out instead of just printing "declined".
+const (
+	DUALFUND_DECLINE_USER                 = 0x01 // responder operator said no
+	DUALFUND_DECLINE_INSUFFICIENT_BALANCE = 0x02 // can't cover remoteAmt
+	DUALFUND_DECLINE_UNSUPPORTED_COIN     = 0x03 // don't have that cointype's wallet
+	DUALFUND_DECLINE_ALREADY_PENDING      = 0x04 // already mid dual-fund w/ someone
+)
+
+// DualFundInProg holds the state of a dual-funded channel open that hasn't
+// finished yet.  It lives on LNode.InProg alongside the single-sided fields
+// so there's still only ever one channel open in flight per node; Dual is
+// nil unless a dualfund round is active.
+type DualFundInProg struct {
+	PeerIdx uint32
+	ChanIdx uint32
+
+	CoinType uint32
+
+	OurAmt   int64 // what we're putting in
+	TheirAmt int64 // what we asked them to put in
+	InitSend int64 // initial push, from initiator to responder
+
+	// OurInputs is what FreezeContribution actually froze to back OurAmt;
+	// it's what gets folded into the joint funding tx alongside the other
+	// side's inputs.
+	OurInputs []*wire.TxIn
+
+	OurFundPub   [33]byte
+	TheirFundPub [33]byte
+
+	// set once the joint funding tx has been fully assembled and signed
+	OurFundingTxid *wire.ShaHash
+
+	Responder bool // true if we're the side being asked to contribute
+}
+
+// channelFundKeyGen builds the bip32 path for a dual-funded channel's
+// 2-of-2 funding key, the same pattern dlc.go's portxoKeyGenFor uses for a
+// contract's keys: peer and channel index pin Step[3]/Step[4], "use" pins
+// Step[2] (UseChannelFund).
+func channelFundKeyGen(peerIdx, cIdx uint32) (k portxo.KeyGen) {
+	k.Depth = 5
+	k.Step[0] = 44 | 1<<31
+	k.Step[1] = 0 | 1<<31
+	k.Step[2] = UseChannelFund
+	k.Step[3] = peerIdx
+	k.Step[4] = cIdx
+	return k
+}
+
+// sortFundPubs returns a, b in canonical (lexicographic) order, the same
+// order every 2-of-2 script in this package embeds its pubkeys in, so both
+// sides independently derive identical scripts and witness ordering
+// regardless of which one is "ours" vs "theirs".
+func sortFundPubs(a, b [33]byte) (first, second [33]byte) {
+	if bytes.Compare(b[:], a[:]) < 0 {
+		return b, a
+	}
+	return a, b
+}
+
+// twoOfTwoRedeemScript builds a bare 2-of-2 multisig redeem script for
+// pubA/pubB, used both as a P2WSH funding output (wrapped by
+// twoOfTwoFundingOutput) and, unwrapped, as the script BIP143 sighashing
+// needs when spending that output - dualfund's joint funding tx and dlc's
+// contract funding tx are both exactly this shape.
+func twoOfTwoRedeemScript(pubA, pubB [33]byte) []byte {
+	pubA, pubB = sortFundPubs(pubA, pubB)
+
+	redeem := make([]byte, 0, 1+1+33+1+33+1)
+	redeem = append(redeem, 0x52) // OP_2
+	redeem = append(redeem, byte(len(pubA)))
+	redeem = append(redeem, pubA[:]...)
+	redeem = append(redeem, byte(len(pubB)))
+	redeem = append(redeem, pubB[:]...)
+	redeem = append(redeem, 0x52) // OP_2
+	redeem = append(redeem, 0xae) // OP_CHECKMULTISIG
+	return redeem
+}
+
+// twoOfTwoFundingOutput wraps twoOfTwoRedeemScript's script as the P2WSH
+// output a joint funding tx pays into, sized at the full capacity.
+func twoOfTwoFundingOutput(pubA, pubB [33]byte, amt int64) *wire.TxOut {
+	redeem := twoOfTwoRedeemScript(pubA, pubB)
+	h := sha256.Sum256(redeem)
+	pkScript := make([]byte, 0, 2+32)
+	pkScript = append(pkScript, 0x00, 0x20) // OP_0, push 32
+	pkScript = append(pkScript, h[:]...)
+
+	return wire.NewTxOut(amt, pkScript)
+}
+
+// DualFundChannel starts a dual-funded channel open with the currently
+// connected peer.  Unlike FundChannel, capacity is split up front: we ask
+// the remote party to contribute remoteAmt of their own utxos on top of
+// the localAmt we're putting in ourselves.  The two sides' inputs get
+// interleaved into one joint funding tx, assembled across the REQ / ACCEPT
+// / SIGS round trip below.
+func (nd *LnNode) DualFundChannel(coinType uint32, localAmt, remoteAmt, initialPush int64) error {
+	if nd.RemoteCon == nil || nd.RemoteCon.RemotePub == nil {
+		return fmt.Errorf("not connected to anyone")
+	}
+	if nd.InProg.Dual != nil {
+		return fmt.Errorf("dual-fund with peer %d not done yet", nd.InProg.Dual.PeerIdx)
+	}
+	if localAmt < 1 || remoteAmt < 0 {
+		return fmt.Errorf("can't have negative amounts")
+	}
+	if initialPush > localAmt+remoteAmt {
+		return fmt.Errorf("can't push %d in %d capacity channel", initialPush, localAmt+remoteAmt)
+	}
+
+	var peerArr [33]byte
+	copy(peerArr[:], nd.RemoteCon.RemotePub.SerializeCompressed())
+
+	peerIdx, cIdx, err := nd.NextIdxForPeer(peerArr)
+	if err != nil {
+		return err
+	}
+	if err := nd.RegisterPeer(peerIdx); err != nil {
+		return err
+	}
+
+	ourIns, err := nd.BaseWallet.FreezeContribution(localAmt)
+	if err != nil {
+		return err
+	}
+	fundPub := nd.GetUsePub(channelFundKeyGen(peerIdx, cIdx), UseChannelFund)
+
+	nd.InProg.Dual = &DualFundInProg{
+		PeerIdx:    peerIdx,
+		ChanIdx:    cIdx,
+		CoinType:   coinType,
+		OurAmt:     localAmt,
+		TheirAmt:   remoteAmt,
+		InitSend:   initialPush,
+		OurInputs:  ourIns,
+		OurFundPub: fundPub,
+	}
+
+	tx := wire.NewMsgTx()
+	for _, in := range ourIns {
+		tx.AddTxIn(in)
+	}
+	var txBuf bytes.Buffer
+	if err := tx.Serialize(&txBuf); err != nil {
+		return err
+	}
+
+	msg := []byte{MSGID_DUALFUND_REQ}
+	msg = append(msg, lnutil.U32tB(coinType)...)
+	msg = append(msg, lnutil.I64tB(localAmt)...)
+	msg = append(msg, lnutil.I64tB(remoteAmt)...)
+	msg = append(msg, lnutil.I64tB(initialPush)...)
+	msg = append(msg, fundPub[:]...)
+	msg = append(msg, txBuf.Bytes()...)
+
+	_, err = nd.RemoteCon.Write(msg)
+	return err
+}
+
+// DualFundReqHandler runs on the responder side on receipt of
+// MSGID_DUALFUND_REQ.  It either freezes enough of our own utxos to cover
+// the requested contribution, folds them in alongside the initiator's
+// already-frozen inputs, adds the joint funding output, signs our side,
+// and replies with MSGID_DUALFUND_ACCEPT, or sends back a
+// MSGID_DUALFUND_DECLINE with a reason code.
+func (nd *LnNode) DualFundReqHandler(msg []byte, peerArr [33]byte) error {
+	if nd.InProg.Dual != nil {
+		// this request isn't the dualfund we already have in flight, so
+		// declining it must not go through sendDualFundDecline - that
+		// would wipe out our own legitimate InProg.Dual as a side effect
+		// of rejecting someone else's unsolicited new offer.
+		_, err := nd.RemoteCon.Write([]byte{MSGID_DUALFUND_DECLINE, DUALFUND_DECLINE_ALREADY_PENDING})
+		return err
+	}
+	if len(msg) < dualFundReqFixedLen {
+		return fmt.Errorf("DualFundReqHandler: bad message length %d", len(msg))
+	}
+
+	coinType := lnutil.BtU32(msg[1:5])
+	localAmt := lnutil.BtI64(msg[5:13])
+	remoteAmt := lnutil.BtI64(msg[13:21])
+	initialPush := lnutil.BtI64(msg[21:29])
+	var theirFundPub [33]byte
+	copy(theirFundPub[:], msg[29:62])
+
+	tx := wire.NewMsgTx()
+	if err := tx.Deserialize(bytes.NewReader(msg[dualFundReqFixedLen:])); err != nil {
+		return fmt.Errorf("DualFundReqHandler: bad funding tx: %s", err.Error())
+	}
+
+	if coinType != nd.BaseWallet.Params().HDCoinType {
+		return nd.sendDualFundDecline(DUALFUND_DECLINE_UNSUPPORTED_COIN)
+	}
+
+	ourIns, err := nd.BaseWallet.FreezeContribution(remoteAmt)
+	if err != nil {
+		return nd.sendDualFundDecline(DUALFUND_DECLINE_INSUFFICIENT_BALANCE)
+	}
+
+	peerIdx, cIdx, err := nd.NextIdxForPeer(peerArr)
+	if err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(ourIns) // already returning the real error below
+		return err
+	}
+	if err := nd.RegisterPeer(peerIdx); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(ourIns) // already returning the real error below
+		return err
+	}
+	fundPub := nd.GetUsePub(channelFundKeyGen(peerIdx, cIdx), UseChannelFund)
+
+	for _, in := range ourIns {
+		tx.AddTxIn(in)
+	}
+	tx.AddTxOut(twoOfTwoFundingOutput(theirFundPub, fundPub, localAmt+remoteAmt))
+
+	if err := nd.BaseWallet.SignMyInputs(tx); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(ourIns) // already returning the real error below
+		return err
+	}
+
+	nd.InProg.Dual = &DualFundInProg{
+		PeerIdx:      peerIdx,
+		ChanIdx:      cIdx,
+		CoinType:     coinType,
+		OurAmt:       remoteAmt,
+		TheirAmt:     localAmt,
+		InitSend:     initialPush,
+		OurInputs:    ourIns,
+		OurFundPub:   fundPub,
+		TheirFundPub: theirFundPub,
+		Responder:    true,
+	}
+
+	var txBuf bytes.Buffer
+	if err := tx.Serialize(&txBuf); err != nil {
+		return err
+	}
+
+	out := []byte{MSGID_DUALFUND_ACCEPT}
+	out = append(out, fundPub[:]...)
+	out = append(out, txBuf.Bytes()...)
+	_, err = nd.RemoteCon.Write(out)
+	return err
+}
+
+// sendDualFundDecline writes a typed decline back to the peer and clears
+// any in-progress state we may have started building, releasing the
+// inputs that state had frozen.
+func (nd *LnNode) sendDualFundDecline(reason uint8) error {
+	if nd.InProg.Dual != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // the decline goes out regardless
+	}
+	nd.InProg.Dual = nil
+	msg := []byte{MSGID_DUALFUND_DECLINE, reason}
+	_, err := nd.RemoteCon.Write(msg)
+	return err
+}
+
+// DualFundDecline is the shell-facing equivalent of sendDualFundDecline,
+// used when the local operator rejects an incoming dualfund offer rather
+// than the node doing so automatically.
+func (nd *LnNode) DualFundDecline() error {
+	if nd.InProg.Dual == nil {
+		return fmt.Errorf("no dualfund offer pending")
+	}
+	return nd.sendDualFundDecline(DUALFUND_DECLINE_USER)
+}
+
+// DualFundDeclineHandler runs on the initiator side and surfaces the
+// responder's reason back up through the normal error path.
+func (nd *LnNode) DualFundDeclineHandler(msg []byte) error {
+	if nd.InProg.Dual != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // the decline itself is the error reported below
+	}
+	nd.InProg.Dual = nil
+	if len(msg) < 2 {
+		return fmt.Errorf("dualfund declined (no reason given)")
+	}
+	switch msg[1] {
+	case DUALFUND_DECLINE_USER:
+		return fmt.Errorf("dualfund declined: peer said no")
+	case DUALFUND_DECLINE_INSUFFICIENT_BALANCE:
+		return fmt.Errorf("dualfund declined: peer can't cover their side")
+	case DUALFUND_DECLINE_UNSUPPORTED_COIN:
+		return fmt.Errorf("dualfund declined: peer doesn't run that cointype")
+	case DUALFUND_DECLINE_ALREADY_PENDING:
+		return fmt.Errorf("dualfund declined: peer already has one pending")
+	default:
+		return fmt.Errorf("dualfund declined: unknown reason %d", msg[1])
+	}
+}
+
+// DualFundAccept is called by the responder's operator to confirm the
+// contribution the node already froze in DualFundReqHandler.  Everything
+// past the freeze is driven by message handlers, so this just exists to
+// give a human a point to abort before signatures go out.
+func (nd *LnNode) DualFundAccept() error {
+	if nd.InProg.Dual == nil || !nd.InProg.Dual.Responder {
+		return fmt.Errorf("no incoming dualfund offer to accept")
+	}
+	return nil
+}
+
+// DualFundAcceptHandler runs on the initiator side on receipt of
+// MSGID_DUALFUND_ACCEPT.  The responder has already folded its inputs and
+// the joint output into the tx and signed its own side; this signs our
+// side, broadcasts the now fully-signed funding tx, and hands a copy back
+// to the responder so it has the final txid too.
+func (nd *LnNode) DualFundAcceptHandler(msg []byte) error {
+	if nd.InProg.Dual == nil || nd.InProg.Dual.Responder {
+		return fmt.Errorf("DualFundAcceptHandler: no matching dualfund in progress")
+	}
+	if len(msg) < 34 {
+		return fmt.Errorf("DualFundAcceptHandler: bad message length %d", len(msg))
+	}
+
+	var theirFundPub [33]byte
+	copy(theirFundPub[:], msg[1:34])
+	nd.InProg.Dual.TheirFundPub = theirFundPub
+
+	tx := wire.NewMsgTx()
+	if err := tx.Deserialize(bytes.NewReader(msg[34:])); err != nil {
+		return fmt.Errorf("DualFundAcceptHandler: bad funding tx: %s", err.Error())
+	}
+
+	wantOut := twoOfTwoFundingOutput(nd.InProg.Dual.OurFundPub, theirFundPub,
+		nd.InProg.Dual.OurAmt+nd.InProg.Dual.TheirAmt)
+	if len(tx.TxOut) != 1 || tx.TxOut[0].Value != wantOut.Value ||
+		!bytes.Equal(tx.TxOut[0].PkScript, wantOut.PkScript) {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // already returning the real error below
+		nd.InProg.Dual = nil
+		return fmt.Errorf("DualFundAcceptHandler: funding tx doesn't pay our 2-of-2")
+	}
+
+	if err := nd.BaseWallet.SignMyInputs(tx); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // already returning the real error below
+		nd.InProg.Dual = nil
+		return err
+	}
+
+	txid := tx.TxSha()
+	nd.InProg.Dual.OurFundingTxid = &txid
+
+	if err := nd.BaseWallet.PushTx(tx); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // already returning the real error below
+		nd.InProg.Dual = nil
+		return err
+	}
+
+	var txBuf bytes.Buffer
+	if err := tx.Serialize(&txBuf); err != nil {
+		return err
+	}
+	sigMsg := []byte{MSGID_DUALFUND_SIGS}
+	sigMsg = append(sigMsg, txBuf.Bytes()...)
+	_, err := nd.RemoteCon.Write(sigMsg)
+
+	nd.InProg.Dual = nil
+	return err
+}
+
+// DualFundSigHandler runs on the responder side on receipt of
+// MSGID_DUALFUND_SIGS: the funding tx now carries both sides' signatures,
+// so it checks the output still matches the agreed 2-of-2 before
+// broadcasting it, the same way DualFundAcceptHandler does on the other
+// side.
+func (nd *LnNode) DualFundSigHandler(msg []byte) error {
+	if nd.InProg.Dual == nil || !nd.InProg.Dual.Responder {
+		return fmt.Errorf("DualFundSigHandler: no matching dualfund in progress")
+	}
+	if len(msg) < 2 {
+		return fmt.Errorf("DualFundSigHandler: bad message length %d", len(msg))
+	}
+
+	tx := wire.NewMsgTx()
+	if err := tx.Deserialize(bytes.NewReader(msg[1:])); err != nil {
+		return fmt.Errorf("DualFundSigHandler: bad funding tx: %s", err.Error())
+	}
+
+	wantOut := twoOfTwoFundingOutput(nd.InProg.Dual.OurFundPub, nd.InProg.Dual.TheirFundPub,
+		nd.InProg.Dual.OurAmt+nd.InProg.Dual.TheirAmt)
+	if len(tx.TxOut) != 1 || tx.TxOut[0].Value != wantOut.Value ||
+		!bytes.Equal(tx.TxOut[0].PkScript, wantOut.PkScript) {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // already returning the real error below
+		nd.InProg.Dual = nil
+		return fmt.Errorf("DualFundSigHandler: funding tx doesn't pay our 2-of-2")
+	}
+
+	if err := nd.BaseWallet.PushTx(tx); err != nil {
+		_ = nd.BaseWallet.UnfreezeContribution(nd.InProg.Dual.OurInputs) // already returning the real error below
+		nd.InProg.Dual = nil
+		return err
+	}
+
+	txid := tx.TxSha()
+	nd.InProg.Dual.OurFundingTxid = &txid
+	nd.InProg.Dual = nil
+	return nil
+}
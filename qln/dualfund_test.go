@@ -0,0 +1,44 @@
+package qln
+
+import "testing"
+
+func TestSortFundPubs(t *testing.T) {
+	var lo, hi [33]byte
+	lo[0] = 0x02
+	hi[0] = 0x03
+
+	first, second := sortFundPubs(lo, hi)
+	if first != lo || second != hi {
+		t.Fatalf("sortFundPubs(lo, hi): want (lo, hi), got (%x, %x)", first, second)
+	}
+
+	first, second = sortFundPubs(hi, lo)
+	if first != lo || second != hi {
+		t.Fatalf("sortFundPubs(hi, lo): want (lo, hi), got (%x, %x)", first, second)
+	}
+}
+
+// TestTwoOfTwoRedeemScriptOrderIndependent makes sure both sides end up
+// with the identical redeem script (and so the identical funding
+// output/witness ordering) no matter which of them is "ours" vs "theirs".
+func TestTwoOfTwoRedeemScriptOrderIndependent(t *testing.T) {
+	var a, b [33]byte
+	a[0] = 0x02
+	b[0] = 0x03
+
+	ab := twoOfTwoRedeemScript(a, b)
+	ba := twoOfTwoRedeemScript(b, a)
+	if string(ab) != string(ba) {
+		t.Fatalf("twoOfTwoRedeemScript not order independent:\na,b: %x\nb,a: %x", ab, ba)
+	}
+
+	// OP_2 <33-byte pub> <33-byte pub> OP_2 OP_CHECKMULTISIG, lower pub first
+	want := []byte{0x52, 33}
+	want = append(want, a[:]...)
+	want = append(want, 33)
+	want = append(want, b[:]...)
+	want = append(want, 0x52, 0xae)
+	if string(ab) != string(want) {
+		t.Fatalf("twoOfTwoRedeemScript:\nwant %x\ngot  %x", want, ab)
+	}
+}
@@ -0,0 +1,276 @@
+package qln
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/lightningnetwork/lnd/lnutil"
+)
+
+// BKTPeers holds one sub-bucket per peer we've ever talked to, keyed by
+// the peer's 4-byte index (the same index GetPeerIdx/NextIdxForPeer use).
+// RegisterPeer is what actually guarantees that invariant; MarkPersistentPeer
+// just adds KEYPersist/KEYPeerAdr on top of a sub-bucket that should
+// already be there.
+var BKTPeers = []byte("peers")
+
+var (
+	KEYPersist = []byte("persist") // presence means "reconnect automatically"
+	KEYPeerAdr = []byte("adr")     // last-known dial string for that peer
+)
+
+// RegisterPeer ensures peerIdx has a sub-bucket under BKTPeers, so
+// ListChannels can find this peer even if it's never been marked
+// persistent. Every call site that hands out a peer index via
+// NextIdxForPeer should call this right after, the same way
+// MarkPersistentPeer already does as a side effect of its own write.
+func (nd *LnNode) RegisterPeer(peerIdx uint32) error {
+	return nd.LnDB.Update(func(btx *bolt.Tx) error {
+		bkt, err := btx.CreateBucketIfNotExists(BKTPeers)
+		if err != nil {
+			return err
+		}
+		_, err = bkt.CreateBucketIfNotExists(lnutil.U32tB(peerIdx))
+		return err
+	})
+}
+
+const (
+	reconnectInitialDelay = time.Second
+	reconnectMaxDelay     = time.Minute * 5
+)
+
+// persistentPeers tracks which peers already have a reconnect goroutine
+// running, so MarkPersistentPeer and StartPersistentPeers can't both spin
+// one up for the same peer; persistentQuit holds the channel that tells
+// that peer's goroutine to stop.
+var (
+	persistentMtx   sync.Mutex
+	persistentPeers = make(map[uint32]bool)
+	persistentQuit  = make(map[uint32]chan struct{})
+)
+
+// MarkPersistentPeer flags peerIdx so the node redials it whenever the
+// connection drops, instead of waiting for the operator to notice and
+// reconnect by hand.  peerIdx has to be who we're currently connected to,
+// since that's the only place the node's ever heard a dial address from.
+func (nd *LnNode) MarkPersistentPeer(peerIdx uint32) error {
+	if !nd.connectedTo(peerIdx) {
+		return fmt.Errorf("not connected to peer %d", peerIdx)
+	}
+	adr := nd.RemoteCon.RemoteAddr().String()
+
+	err := nd.LnDB.Update(func(btx *bolt.Tx) error {
+		bkt, err := btx.CreateBucketIfNotExists(BKTPeers)
+		if err != nil {
+			return err
+		}
+		pbkt, err := bkt.CreateBucketIfNotExists(lnutil.U32tB(peerIdx))
+		if err != nil {
+			return err
+		}
+		err = pbkt.Put(KEYPersist, []byte{1})
+		if err != nil {
+			return err
+		}
+		return pbkt.Put(KEYPeerAdr, []byte(adr))
+	})
+	if err != nil {
+		return err
+	}
+
+	nd.startPersistentPeer(peerIdx)
+	return nil
+}
+
+// ListPersistentPeers returns the peer indexes currently flagged
+// persistent, for the lncli / shell "listpersistent" command.
+func (nd *LnNode) ListPersistentPeers() ([]uint32, error) {
+	var out []uint32
+	err := nd.LnDB.View(func(btx *bolt.Tx) error {
+		bkt := btx.Bucket(BKTPeers)
+		if bkt == nil {
+			return nil // nobody's ever connected; nothing persistent either
+		}
+		return bkt.ForEach(func(k, _ []byte) error {
+			pbkt := bkt.Bucket(k)
+			if pbkt == nil || pbkt.Get(KEYPersist) == nil {
+				return nil
+			}
+			out = append(out, lnutil.BtU32(k))
+			return nil
+		})
+	})
+	return out, err
+}
+
+// StartPersistentPeers rehydrates every persistent flag from the DB and
+// kicks off a reconnect goroutine for each one.  Call once at node
+// startup, after both the DB and the dialer are ready to use.
+func (nd *LnNode) StartPersistentPeers() error {
+	peerIdxs, err := nd.ListPersistentPeers()
+	if err != nil {
+		return err
+	}
+	for _, peerIdx := range peerIdxs {
+		nd.startPersistentPeer(peerIdx)
+	}
+	return nil
+}
+
+// startPersistentPeer launches the reconnect goroutine for peerIdx unless
+// one's already running.
+func (nd *LnNode) startPersistentPeer(peerIdx uint32) {
+	persistentMtx.Lock()
+	defer persistentMtx.Unlock()
+	if persistentPeers[peerIdx] {
+		return
+	}
+	persistentPeers[peerIdx] = true
+	quit := make(chan struct{})
+	persistentQuit[peerIdx] = quit
+	go nd.runPersistentPeer(peerIdx, quit)
+}
+
+// StopPersistentPeer turns off automatic reconnect for peerIdx: it clears
+// the DB flag and, if a reconnect goroutine for peerIdx is currently
+// running, tells it to stop instead of polling forever.
+func (nd *LnNode) StopPersistentPeer(peerIdx uint32) error {
+	err := nd.LnDB.Update(func(btx *bolt.Tx) error {
+		bkt := btx.Bucket(BKTPeers)
+		if bkt == nil {
+			return nil
+		}
+		pbkt := bkt.Bucket(lnutil.U32tB(peerIdx))
+		if pbkt == nil {
+			return nil
+		}
+		return pbkt.Delete(KEYPersist)
+	})
+	if err != nil {
+		return err
+	}
+
+	persistentMtx.Lock()
+	defer persistentMtx.Unlock()
+	if quit, ok := persistentQuit[peerIdx]; ok {
+		close(quit)
+		delete(persistentQuit, peerIdx)
+	}
+	delete(persistentPeers, peerIdx)
+	return nil
+}
+
+// connectedTo reports whether we're currently connected to peerIdx
+// specifically, not just to someone.
+func (nd *LnNode) connectedTo(peerIdx uint32) bool {
+	if nd.RemoteCon == nil || nd.RemoteCon.RemotePub == nil {
+		return false
+	}
+	curIdx, err := nd.GetPeerIdx(nd.RemoteCon.RemotePub)
+	return err == nil && curIdx == peerIdx
+}
+
+// peerAddr reads back the last dial string MarkPersistentPeer saved for
+// peerIdx.
+func (nd *LnNode) peerAddr(peerIdx uint32) (string, error) {
+	var adr string
+	err := nd.LnDB.View(func(btx *bolt.Tx) error {
+		bkt := btx.Bucket(BKTPeers)
+		if bkt == nil {
+			return fmt.Errorf("no peers bucket")
+		}
+		pbkt := bkt.Bucket(lnutil.U32tB(peerIdx))
+		if pbkt == nil {
+			return fmt.Errorf("no bucket for peer %d", peerIdx)
+		}
+		b := pbkt.Get(KEYPeerAdr)
+		if b == nil {
+			return fmt.Errorf("peer %d has no known address", peerIdx)
+		}
+		adr = string(b)
+		return nil
+	})
+	return adr, err
+}
+
+// runPersistentPeer is the reconnect loop for a single persistent peer.
+// RemoteCon has no disconnect callback to block on, so this just polls;
+// on every drop it redials with exponential backoff (1s initial, capped
+// at 5m, reset back to 1s as soon as a handshake actually completes), the
+// same shape as the standard Tendermint-style p2p reconnect. quit, closed
+// by StopPersistentPeer, is what lets this goroutine ever actually stop.
+//
+// The node only ever tracks one RemoteCon, so with more than one
+// persistent peer configured this backs off from redialing whenever
+// RemoteCon is already pointed at somebody else, rather than stealing the
+// connection out from under them every poll cycle.
+func (nd *LnNode) runPersistentPeer(peerIdx uint32, quit <-chan struct{}) {
+	delay := reconnectInitialDelay
+	for {
+		select {
+		case <-quit:
+			return
+		case <-time.After(time.Second):
+		}
+
+		if nd.connectedTo(peerIdx) {
+			delay = reconnectInitialDelay
+			continue
+		}
+		if nd.RemoteCon != nil && nd.RemoteCon.RemotePub != nil {
+			// connected to a different peer - the node only tracks one
+			// RemoteCon at a time, so redialing now would just steal the
+			// connection out from under whoever that is. Wait for it to
+			// free up instead of fighting over it every poll cycle.
+			continue
+		}
+
+		adr, err := nd.peerAddr(peerIdx)
+		if err != nil {
+			continue // don't know how to dial yet; keep polling
+		}
+
+		err = nd.DialPeer(adr)
+		if err != nil {
+			select {
+			case <-quit:
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > reconnectMaxDelay {
+				delay = reconnectMaxDelay
+			}
+			continue
+		}
+
+		delay = reconnectInitialDelay
+		// resumeAllWithPeer's error has nowhere good to go: this is a
+		// background goroutine, not the interactive shell, and it'll just
+		// get tried again next time this peer reconnects.
+		_ = nd.resumeAllWithPeer(peerIdx)
+	}
+}
+
+// resumeAllWithPeer walks every channel open with peerIdx and re-sends
+// whatever message a non-final State would have sent, so a push or
+// funding round interrupted by a dropped connection finishes on its own
+// instead of needing a manual `fix`.
+func (nd *LnNode) resumeAllWithPeer(peerIdx uint32) error {
+	for cIdx := uint32(0); ; cIdx++ {
+		qc, err := nd.GetQchanByIdx(peerIdx, cIdx)
+		if err != nil {
+			return nil // ran off the end of this peer's channels
+		}
+		if qc.State.Delta == 0 {
+			continue // nothing in flight on this one
+		}
+		err = nd.SendNextMsg(qc)
+		if err != nil {
+			return err
+		}
+	}
+}
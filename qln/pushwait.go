@@ -0,0 +1,58 @@
+package qln
+
+import (
+	"sync"
+)
+
+// PushNotification reports that a channel's balance moved.  It doesn't
+// carry a block hash/height the way a confirmed on-chain tx would, since a
+// push is an off-chain state update with no block of its own; callers that
+// need to correlate with a block (GetTransactions) leave those fields
+// zero for push-only notifications.
+type PushNotification struct {
+	PeerIdx uint32
+	ChanIdx uint32
+	Delta   int64
+}
+
+// pushSubs fans every completed push out to whoever's currently listening
+// via SubscribePushNotifications, e.g. the GetTransactions RPC stream.
+var (
+	pushSubMtx sync.Mutex
+	pushSubs   = make(map[chan PushNotification]struct{})
+)
+
+// SubscribePushNotifications registers a new listener for every push this
+// node completes from here on.  The caller must call the returned
+// unsubscribe func when done listening, or the channel leaks.
+func SubscribePushNotifications() (<-chan PushNotification, func()) {
+	ch := make(chan PushNotification, 16)
+	pushSubMtx.Lock()
+	pushSubs[ch] = struct{}{}
+	pushSubMtx.Unlock()
+
+	unsubscribe := func() {
+		pushSubMtx.Lock()
+		delete(pushSubs, ch)
+		pushSubMtx.Unlock()
+		close(ch)
+	}
+	return ch, unsubscribe
+}
+
+// publishPushNotification fans a completed push out to every subscriber.
+// Nothing calls this yet: it was meant to fire from the REV handler once
+// a push's elkrem exchange actually lands, but that handler isn't part of
+// this snapshot, so there's no real "push complete" signal anywhere in
+// this tree to hang it off of. Wire it in once that handler exists rather
+// than guessing at a synthetic completion point.
+func publishPushNotification(n PushNotification) {
+	pushSubMtx.Lock()
+	defer pushSubMtx.Unlock()
+	for ch := range pushSubs {
+		select {
+		case ch <- n:
+		default: // slow listener; drop rather than block the pusher
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/lightningnetwork/lnd/dlc"
 	"github.com/lightningnetwork/lnd/lnutil"
 	"github.com/lightningnetwork/lnd/qln"
 )
@@ -56,6 +58,9 @@ func FundChannel(args []string) error {
 	if err != nil {
 		return err
 	}
+	if err := LNode.RegisterPeer(peerIdx); err != nil {
+		return err
+	}
 
 	LNode.InProg.ChanIdx = cIdx
 	LNode.InProg.PeerIdx = peerIdx
@@ -171,7 +176,10 @@ func Push(args []string) error {
 		if err != nil {
 			return err
 		}
-		// such a hack.. obviously need indicator of when state update complete
+		// such a hack.. obviously need indicator of when state update complete.
+		// Awaiting a real completion signal needs the REV handler, which
+		// isn't in this tree, so there's nothing to sync against yet. Keep
+		// the sleep until that's wired up.
 		time.Sleep(time.Millisecond * 25)
 		times--
 	}
@@ -238,6 +246,152 @@ func CloseChannel(args []string) error {
 	return nil
 }
 
+// DualFund is the shell command which starts a dual-funded channel open,
+// where the remote peer contributes some of the capacity itself.
+func DualFund(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("need args: dualfund localAmt remoteAmt (initSend)")
+	}
+	if LNode.RemoteCon == nil || LNode.RemoteCon.RemotePub == nil {
+		return fmt.Errorf("Not connected to anyone")
+	}
+
+	localAmt, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return err
+	}
+	remoteAmt, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return err
+	}
+	initSend := int64(0)
+	if len(args) > 2 {
+		initSend, err = strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return err
+		}
+	}
+	if localAmt+remoteAmt < 1000000 { // limit for now, same as FundChannel
+		return fmt.Errorf("Min channe capacity 1M sat")
+	}
+
+	// see if we can cover our own half.  Doesn't freeze here though;
+	// DualFundChannel freezes our inputs right before it sends the request.
+	_, _, err = SCon.TS.PickUtxos(localAmt, true)
+	if err != nil {
+		return err
+	}
+
+	return LNode.DualFundChannel(LNode.BaseWallet.Params().HDCoinType, localAmt, remoteAmt, initSend)
+}
+
+// DualFundAccept confirms an incoming dualfund offer on the responder side.
+func DualFundAccept(args []string) error {
+	return LNode.DualFundAccept()
+}
+
+// DualFundDecline rejects an incoming dualfund offer on the responder side.
+func DualFundDecline(args []string) error {
+	return LNode.DualFundDecline()
+}
+
+// Persist marks a peer as persistent, so the node automatically redials it
+// (and resumes any channels left mid-update) whenever the connection drops.
+func Persist(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("need args: persist peerIdx")
+	}
+	peerIdx64, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return err
+	}
+	return LNode.MarkPersistentPeer(uint32(peerIdx64))
+}
+
+// ListPersistent is the shell command backing `lncli listpersistent`; it
+// prints every peer index currently flagged for auto-reconnect.
+func ListPersistent(args []string) error {
+	peerIdxs, err := LNode.ListPersistentPeers()
+	if err != nil {
+		return err
+	}
+	if len(peerIdxs) == 0 {
+		fmt.Printf("no persistent peers\n")
+		return nil
+	}
+	for _, peerIdx := range peerIdxs {
+		fmt.Printf("peer %d\n", peerIdx)
+	}
+	return nil
+}
+
+// DLCOffer is the shell command which proposes a discreet log contract to
+// the connected peer, freezing our half of the 2-of-2 via FreezeContribution.
+func DLCOffer(args []string) error {
+	if len(args) < 5 {
+		return fmt.Errorf("need args: dlcoffer peer oracleHex Rhex ourAmt theirAmt")
+	}
+	if LNode.RemoteCon == nil || LNode.RemoteCon.RemotePub == nil {
+		return fmt.Errorf("Not connected to anyone")
+	}
+
+	oracleBytes, err := hex.DecodeString(args[1])
+	if err != nil {
+		return err
+	}
+	rBytes, err := hex.DecodeString(args[2])
+	if err != nil {
+		return err
+	}
+	if len(oracleBytes) != 33 || len(rBytes) != 33 {
+		return fmt.Errorf("oracle pubkey and R must be 33 bytes each")
+	}
+	var oracle dlc.Oracle
+	copy(oracle.Pub[:], oracleBytes)
+	copy(oracle.R[:], rBytes)
+
+	ourAmt, err := strconv.ParseInt(args[3], 10, 64)
+	if err != nil {
+		return err
+	}
+	theirAmt, err := strconv.ParseInt(args[4], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	return LNode.DLCOffer(oracle, ourAmt, theirAmt)
+}
+
+// DLCSettle is the shell command which finalizes a contract once the oracle
+// has signed an outcome: it combines the revealed signature with our own
+// payout key and broadcasts the settlement tx.
+func DLCSettle(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("need args: dlcsettle peerIdx contractIdx oracleSigHex")
+	}
+
+	peerIdx64, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return err
+	}
+	cIdx64, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil {
+		return err
+	}
+
+	sigBytes, err := hex.DecodeString(args[2])
+	if err != nil {
+		return err
+	}
+	if len(sigBytes) != 32 {
+		return fmt.Errorf("oracle signature scalar must be 32 bytes")
+	}
+	var oracleSig [32]byte
+	copy(oracleSig[:], sigBytes)
+
+	return LNode.DLCSettle(uint32(peerIdx64), uint32(cIdx64), oracleSig)
+}
+
 // BreakChannel closes the channel without the other party's involvement.
 // The user causing the channel Break has to wait for the OP_CSV timeout
 // before funds can be recovered.  Break output addresses are already in the
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnutil"
+	"github.com/lightningnetwork/lnd/qln"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// rpcServer implements lnrpc.LightningControlServer against the package
+// level LNode, the same node the qlnshell commands above drive.  It exists
+// so tooling that isn't a human at a text prompt (a UI, a test harness, a
+// payment-routing daemon) can drive the node without going through the
+// shell's string parsing.
+type rpcServer struct{}
+
+// newRPCServer returns an rpcServer ready to be handed to
+// lnrpc.RegisterLightningControlServer.
+func newRPCServer() *rpcServer {
+	return &rpcServer{}
+}
+
+func (r *rpcServer) FundChannel(ctx context.Context, in *lnrpc.FundRequest) (*lnrpc.FundResponse, error) {
+	if LNode.RemoteCon == nil || LNode.RemoteCon.RemotePub == nil {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "not connected to anyone")
+	}
+	if in.Capacity < 1000000 { // limit for now, same as the `fund` shell command
+		return nil, grpc.Errorf(codes.InvalidArgument, "min channel capacity 1M sat")
+	}
+	if in.InitialSend > in.Capacity || in.InitialSend < 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument,
+			"can't send %d in %d capacity channel", in.InitialSend, in.Capacity)
+	}
+	if LNode.InProg.PeerIdx != 0 {
+		return nil, grpc.Errorf(codes.FailedPrecondition,
+			"channel with peer %d not done yet", LNode.InProg.PeerIdx)
+	}
+
+	_, _, err := SCon.TS.PickUtxos(in.Capacity, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var peerArr [33]byte
+	copy(peerArr[:], LNode.RemoteCon.RemotePub.SerializeCompressed())
+	peerIdx, cIdx, err := LNode.NextIdxForPeer(peerArr)
+	if err != nil {
+		return nil, err
+	}
+	if err := LNode.RegisterPeer(peerIdx); err != nil {
+		return nil, err
+	}
+
+	LNode.InProg.ChanIdx = cIdx
+	LNode.InProg.PeerIdx = peerIdx
+	LNode.InProg.Amt = in.Capacity
+	LNode.InProg.InitSend = in.InitialSend
+
+	if _, err := LNode.RemoteCon.Write([]byte{qln.MSGID_POINTREQ}); err != nil {
+		return nil, err
+	}
+
+	return &lnrpc.FundResponse{PeerIdx: peerIdx, ChanIdx: cIdx}, nil
+}
+
+// SendPayment reads one PushRequest at a time off the stream and applies
+// each as a push, the streamed equivalent of the shell's `push ... times`
+// loop.  It reports back how many landed before the client closed the
+// stream or a push failed.  A request that can't be pushed at all (wrong
+// peer, unknown or closed channel) ends the RPC with an error instead.
+//
+// There's no real "push complete" signal anywhere in this tree to await -
+// that needs the REV handler, which isn't part of this snapshot - so this
+// uses the same PushChannel + short sleep the shell's Push command uses
+// rather than blocking on one. Switch to awaiting a completion channel
+// once that handler exists to signal it.
+func (r *rpcServer) SendPayment(stream lnrpc.LightningControl_SendPaymentServer) error {
+	if LNode.RemoteCon == nil || LNode.RemoteCon.RemotePub == nil {
+		return grpc.Errorf(codes.FailedPrecondition, "not connected to anyone")
+	}
+	currentPeerIdx, err := LNode.GetPeerIdx(LNode.RemoteCon.RemotePub)
+	if err != nil {
+		return err
+	}
+
+	var applied uint32
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if in.PeerIdx != currentPeerIdx {
+			return grpc.Errorf(codes.FailedPrecondition,
+				"want to push to peer %d but connected to %d", in.PeerIdx, currentPeerIdx)
+		}
+
+		qc, err := LNode.GetQchanByIdx(in.PeerIdx, in.ChanIdx)
+		if err != nil {
+			return err
+		}
+		if qc.CloseData.Closed {
+			return fmt.Errorf("channel %d, %d is closed", in.PeerIdx, in.ChanIdx)
+		}
+
+		if err := LNode.ReloadQchan(qc); err != nil {
+			break // a failed push ends the stream; applied says how far it got
+		}
+		if err := LNode.PushChannel(qc, uint32(in.Amt)); err != nil {
+			break // a failed push ends the stream; applied says how far it got
+		}
+		time.Sleep(time.Millisecond * 25)
+		applied++
+	}
+	return stream.SendAndClose(&lnrpc.PushResponse{PushesApplied: applied})
+}
+
+func (r *rpcServer) CloseChannel(ctx context.Context, in *lnrpc.CloseRequest) (*lnrpc.CloseResponse, error) {
+	if LNode.RemoteCon == nil || LNode.RemoteCon.RemotePub == nil {
+		return nil, grpc.Errorf(codes.FailedPrecondition, "not connected to anyone")
+	}
+
+	currentPeerIdx, err := LNode.GetPeerIdx(LNode.RemoteCon.RemotePub)
+	if err != nil {
+		return nil, err
+	}
+	if in.PeerIdx != currentPeerIdx {
+		return nil, grpc.Errorf(codes.FailedPrecondition,
+			"want to close with peer %d but connected to %d", in.PeerIdx, currentPeerIdx)
+	}
+
+	qc, err := LNode.GetQchanByIdx(in.PeerIdx, in.ChanIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := qc.SimpleCloseTx()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := LNode.SignSimpleClose(qc, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	opArr := lnutil.OutPointToBytes(qc.Op)
+	msg := []byte{qln.MSGID_CLOSEREQ}
+	msg = append(msg, opArr[:]...)
+	msg = append(msg, sig...)
+
+	if _, err := LNode.RemoteCon.Write(msg); err != nil {
+		return nil, err
+	}
+	return &lnrpc.CloseResponse{}, nil
+}
+
+func (r *rpcServer) BreakChannel(ctx context.Context, in *lnrpc.BreakRequest) (*lnrpc.BreakResponse, error) {
+	qc, err := LNode.GetQchanByIdx(in.PeerIdx, in.ChanIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	qc.State.Delta = 0
+	tx, err := LNode.SignBreakTx(qc)
+	if err != nil {
+		return nil, err
+	}
+	if err := LNode.BaseWallet.PushTx(tx); err != nil {
+		return nil, err
+	}
+
+	txid := tx.TxSha()
+	return &lnrpc.BreakResponse{BreakTxid: txid[:]}, nil
+}
+
+// GetTransactions streams a TransactionNotification for every push that
+// publishPushNotification fans out.  Nothing in this tree calls
+// publishPushNotification (see its doc comment), so the stream sends
+// nothing.  Follows btcwallet's GetTransactions rule:
+// starting_block_hash and starting_block_height are mutually exclusive.
+//
+// Pushes are off-chain state updates with no block of their own (see
+// PushNotification's doc comment), so there's no block to resume from yet;
+// rather than silently accept and ignore a starting point, reject it until
+// this is backed by real block-indexed history.
+func (r *rpcServer) GetTransactions(in *lnrpc.GetTransactionsRequest, stream lnrpc.LightningControl_GetTransactionsServer) error {
+	if len(in.StartingBlockHash) != 0 && in.StartingBlockHeight != 0 {
+		return grpc.Errorf(codes.InvalidArgument,
+			"set starting_block_hash or starting_block_height, not both")
+	}
+	if len(in.StartingBlockHash) != 0 || in.StartingBlockHeight != 0 {
+		return grpc.Errorf(codes.Unimplemented,
+			"starting from a block is not supported yet")
+	}
+
+	notifs, unsubscribe := qln.SubscribePushNotifications()
+	defer unsubscribe()
+
+	for notif := range notifs {
+		out := &lnrpc.TransactionNotification{
+			PeerIdx: notif.PeerIdx,
+			ChanIdx: notif.ChanIdx,
+			Delta:   notif.Delta,
+		}
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *rpcServer) ListChannels(ctx context.Context, in *lnrpc.ListChannelsRequest) (*lnrpc.ListChannelsResponse, error) {
+	summaries, err := LNode.ListChannels()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &lnrpc.ListChannelsResponse{}
+	for _, s := range summaries {
+		resp.Channels = append(resp.Channels, &lnrpc.ChannelInfo{
+			PeerIdx:    s.PeerIdx,
+			ChanIdx:    s.ChanIdx,
+			Capacity:   s.Capacity,
+			StateDelta: s.StateDelta,
+			Closed:     s.Closed,
+		})
+	}
+	return resp, nil
+}
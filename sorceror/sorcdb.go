@@ -171,28 +171,89 @@ func (s *Sorceror) AddMsg(sm StateMsg) error {
 		copy(sigIdxBytes[4:10], stateNumBytes[2:])
 		copy(sigIdxBytes[10:], sm.Sig[:])
 
+		// Txid is only ever stored truncated to 8 bytes, so two different
+		// channels' states can land on the same key.  Rather than
+		// clobbering the earlier entry, append; CheckTxids knows to try
+		// every 74-byte candidate under a key instead of assuming there's
+		// just one.
+		existing := txidbkt.Get(sm.Txid[:8])
+		sigIdxBytes = append(append([]byte{}, existing...), sigIdxBytes...)
+
 		// save sigIdx into the txid bucket.
 		return txidbkt.Put(sm.Txid[:8], sigIdxBytes)
 	})
 }
 
-// CheckTxids takes a slice of txids and sees if any are in the
-// DB.  If there is, SorceMsgs are returned which can then be turned into txs.
-// can take the txid slice direct from a msgBlock after block has been
-// merkle-checked.
+// CheckTxids takes a slice of txids and sees if any are in the DB.  If
+// there is, StateMsgs are returned which BuildGrabTx can turn into a
+// sweep tx.  can take the txid slice direct from a msgBlock after the
+// block has been merkle-checked.
+//
+// A given txid[:8] key can hold more than one 74-byte candidate (see the
+// collision comment in AddMsg), so a single hit can come back as more
+// than one StateMsg, all sharing the same Txid.  It's up to the caller
+// (Watch) to try each candidate's grab tx and keep only the one whose
+// signature actually verifies.
 func (s *Sorceror) CheckTxids(inTxids []wire.ShaHash) ([]StateMsg, error) {
-	var hitTxids []StateMsg
+	var hits []StateMsg
 	err := s.SorceDB.View(func(btx *bolt.Tx) error {
-		bkt := btx.Bucket(BUCKETTxid)
+		txidbkt := btx.Bucket(BUCKETTxid)
+		if txidbkt == nil {
+			return fmt.Errorf("no txid bucket")
+		}
+		mbkt := btx.Bucket(BUCKETPKHMap)
+		if mbkt == nil {
+			return fmt.Errorf("no PKHmap bucket")
+		}
+		allChanbkt := btx.Bucket(BUCKETChandata)
+		if allChanbkt == nil {
+			return fmt.Errorf("no Chandata bucket")
+		}
+
 		for _, txid := range inTxids {
-			idxsig := bkt.Get(txid[:8])
-			if idxsig != nil { // hit!!!!1 whoa!
-				// Call SorceMsg construction function here
+			blob := txidbkt.Get(txid[:8])
+			if blob == nil {
+				continue // no hit
+			}
+			if len(blob)%74 != 0 {
+				return fmt.Errorf("txid %x: %d bytes isn't a multiple of 74",
+					txid[:8], len(blob))
+			}
+
+			for len(blob) > 0 {
+				idxsig := blob[:74]
+				blob = blob[74:]
+
+				cIdxBytes := idxsig[:4]
+				pkh := mbkt.Get(cIdxBytes)
+				if pkh == nil {
+					return fmt.Errorf("no pkh for channel idx %x", cIdxBytes)
+				}
+				cbkt := allChanbkt.Bucket(pkh)
+				if cbkt == nil {
+					return fmt.Errorf("no bucket for channel %x", pkh)
+				}
+
+				elkr, err := elkrem.ElkremReceiverFromBytes(cbkt.Get(KEYElkRcv))
+				if err != nil {
+					return err
+				}
+				var stateNumBytes [8]byte
+				copy(stateNumBytes[2:], idxsig[4:10])
+				stateNum := binary.BigEndian.Uint64(stateNumBytes[:])
+
+				elk, err := elkr.AtIndex(stateNum)
+				if err != nil {
+					return err
+				}
+
 				var sm StateMsg
-				copy(sm.Txid[:], txid[:16])
-				// that wasn't it.  make a real function
+				sm.Txid = txid
+				copy(sm.DestPKHScript[:], pkh)
+				sm.Elk = elk
+				copy(sm.Sig[:], idxsig[10:])
 
-				hitTxids = append(hitTxids, sm)
+				hits = append(hits, sm)
 			}
 		}
 		return nil
@@ -202,7 +263,7 @@ func (s *Sorceror) CheckTxids(inTxids []wire.ShaHash) ([]StateMsg, error) {
 		return nil, err
 	}
 
-	return hitTxids, nil
+	return hits, nil
 }
 
 func I64tB(i int64) []byte {
@@ -0,0 +1,299 @@
+package sorceror
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/boltdb/bolt"
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// Sorceror is a watchtower: it holds just enough state, handed to it by a
+// channel participant, to notice a counterparty broadcasting a revoked
+// commitment and sweep the cheater's to-local output before the CSV
+// timeout lets them walk away with it.  It never sees channel balances or
+// anything else beyond what AddDesc / AddMsg are given.
+type Sorceror struct {
+	SorceDB *bolt.DB
+}
+
+// ChanStatic is the per-channel data that doesn't change state to state.
+// It's handed over once via AddDesc; the elkrem receiver and per-state
+// IdxSig blobs (see AddMsg) are what actually grow over the channel's
+// life.  Serializes to exactly 96 bytes so KEYStatic's fixed-size slice in
+// sorcdb.go doesn't have to think about it.
+type ChanStatic struct {
+	DestPKHScript  [20]byte // where a caught breach should be swept to
+	Delay          uint32   // CSV delay, in blocks, on the to-local output
+	CoinType       uint32   // which chain/cointype this channel lives on
+	HAKDBasePriv   [32]byte // scalar tweak; combined with the per-state elkrem hash below gives the revocation privkey for that state
+	LocalRefundPub [33]byte // the non-revoked (timed-out) half of the to-local script
+	reserved       [3]byte  // pad to 96; free for whatever the next field turns out to be
+}
+
+// ToBytes serializes a ChanStatic to its fixed 96-byte form.
+func (cs ChanStatic) ToBytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(cs.DestPKHScript[:])
+	var b4 [4]byte
+	binary.BigEndian.PutUint32(b4[:], cs.Delay)
+	buf.Write(b4[:])
+	binary.BigEndian.PutUint32(b4[:], cs.CoinType)
+	buf.Write(b4[:])
+	buf.Write(cs.HAKDBasePriv[:])
+	buf.Write(cs.LocalRefundPub[:])
+	buf.Write(cs.reserved[:])
+	return buf.Bytes()
+}
+
+// ChanStaticFromBytes deserializes a ChanStatic from its 96-byte form.
+func ChanStaticFromBytes(b []byte) (ChanStatic, error) {
+	var cs ChanStatic
+	if len(b) != 96 {
+		return cs, fmt.Errorf("ChanStatic: need 96 bytes, got %d", len(b))
+	}
+	copy(cs.DestPKHScript[:], b[:20])
+	cs.Delay = binary.BigEndian.Uint32(b[20:24])
+	cs.CoinType = binary.BigEndian.Uint32(b[24:28])
+	copy(cs.HAKDBasePriv[:], b[28:60])
+	copy(cs.LocalRefundPub[:], b[60:93])
+	copy(cs.reserved[:], b[93:96])
+	return cs, nil
+}
+
+// SorceDescriptor is what AddDesc takes: a ChanStatic plus the elkrem seed
+// (ElkZero) needed to start that channel's receiver.  ToBytes() returns
+// the static blob followed by ElkZero; AddDesc drops the trailing 32
+// bytes when it persists KEYStatic, since ElkZero lives in the elkrem
+// receiver bucket instead (see the big comment at the top of sorcdb.go).
+type SorceDescriptor struct {
+	ChanStatic
+	ElkZero wire.ShaHash
+}
+
+// ToBytes serializes a SorceDescriptor to 128 bytes: 96 bytes of
+// ChanStatic followed by the 32-byte ElkZero.
+func (sd SorceDescriptor) ToBytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(sd.ChanStatic.ToBytes())
+	buf.Write(sd.ElkZero[:])
+	return buf.Bytes()
+}
+
+// StateMsg is what a channel participant pushes to the Sorceror every time
+// they advance state: the new elkrem hash, the txid of the commitment
+// they're revoking, and a signature the Sorceror can use to sweep that
+// commitment's to-local output if it's ever broadcast.
+//
+// Amt and OutIdx aren't sent over the wire or persisted; Watch fills them
+// in from the matched on-chain tx right before calling BuildGrabTx, since
+// AddMsg only ever learns the txid (see the 8-byte-key comment in
+// sorcdb.go), not the tx itself.
+type StateMsg struct {
+	DestPKHScript [20]byte
+	Txid          wire.ShaHash
+	Elk           wire.ShaHash
+	Sig           [64]byte
+
+	Amt    int64
+	OutIdx uint32
+}
+
+// deriveRevocationPrivKey combines the per-channel base scalar with the
+// per-state elkrem hash to recover the privkey for that state's revocation
+// pubkey.  Only the Sorceror and the original channel owner can do this;
+// the owner precomputes HAKDBasePriv once and the elkrem hash is what
+// actually changes state to state.
+func deriveRevocationPrivKey(base [32]byte, elk wire.ShaHash) *btcec.PrivateKey {
+	k := new(big.Int).SetBytes(base[:])
+	e := new(big.Int).SetBytes(elk[:])
+	k.Add(k, e)
+	k.Mod(k, btcec.S256().N)
+	priv, _ := btcec.PrivKeyFromBytes(btcec.S256(), k.Bytes())
+	return priv
+}
+
+// breachToLocalScript rebuilds the to-local witness script for a revoked
+// commitment: spendable immediately with the revocation key, or by the
+// honest party's own refund key after the CSV delay.  This has to stay in
+// sync by hand with whatever qln actually puts in the commitment tx, since
+// sorceror intentionally doesn't import qln (it'd make an ordinary
+// watchtower depend on the exact channel state machine it's meant to be
+// independent of).
+func breachToLocalScript(revPub *btcec.PublicKey, delay uint32, refundPub [33]byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder()
+	b.AddOp(txscript.OP_IF)
+	b.AddData(revPub.SerializeCompressed())
+	b.AddOp(txscript.OP_ELSE)
+	b.AddInt64(int64(delay))
+	b.AddOp(txscript.OP_CHECKSEQUENCEVERIFY)
+	b.AddOp(txscript.OP_DROP)
+	b.AddData(refundPub[:])
+	b.AddOp(txscript.OP_ENDIF)
+	b.AddOp(txscript.OP_CHECKSIG)
+	return b.Script()
+}
+
+// chanStatic reads back the ChanStatic a channel was registered with.
+func (s *Sorceror) chanStatic(pkh [20]byte) (ChanStatic, error) {
+	var stc ChanStatic
+	err := s.SorceDB.View(func(btx *bolt.Tx) error {
+		allChanbkt := btx.Bucket(BUCKETChandata)
+		if allChanbkt == nil {
+			return fmt.Errorf("no Chandata bucket")
+		}
+		cbkt := allChanbkt.Bucket(pkh[:])
+		if cbkt == nil {
+			return fmt.Errorf("no bucket for channel %x", pkh)
+		}
+		raw := cbkt.Get(KEYStatic)
+		if raw == nil {
+			return fmt.Errorf("channel %x has no static data", pkh)
+		}
+		var err error
+		stc, err = ChanStaticFromBytes(raw)
+		return err
+	})
+	return stc, err
+}
+
+// grabWitness derives the revocation pubkey and to-local script a StateMsg
+// would need to spend, without yet touching any particular tx.
+func (s *Sorceror) grabWitness(sm StateMsg) (*btcec.PublicKey, []byte, error) {
+	stc, err := s.chanStatic(sm.DestPKHScript)
+	if err != nil {
+		return nil, nil, err
+	}
+	revPriv := deriveRevocationPrivKey(stc.HAKDBasePriv, sm.Elk)
+	script, err := breachToLocalScript(revPriv.PubKey(), stc.Delay, stc.LocalRefundPub)
+	if err != nil {
+		return nil, nil, err
+	}
+	return revPriv.PubKey(), script, nil
+}
+
+// sigVerifies checks that sm.Sig is a valid signature, under the
+// derived revocation pubkey, for grabTx spending its single input with
+// the given witness script and input amount.  This is how Watch tells
+// apart genuine hits from txid[:8] collisions: only the real candidate's
+// signature will check out against this particular breach tx.
+func sigVerifies(grabTx *wire.MsgTx, amt int64, script []byte, sig [64]byte, pub *btcec.PublicKey) bool {
+	hashes := txscript.NewTxSigHashes(grabTx)
+	sigHash, err := txscript.CalcWitnessSigHash(
+		script, hashes, txscript.SigHashAll, grabTx, 0, amt)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	ss := new(big.Int).SetBytes(sig[32:])
+	signature := &btcec.Signature{R: r, S: ss}
+	return signature.Verify(sigHash, pub)
+}
+
+// BuildGrabTx turns a matched StateMsg into a fully witnessed transaction
+// that sweeps the cheater's to-local output to DestPKHScript.  Call it
+// only after filling in Amt/OutIdx from the actual on-chain tx (Watch does
+// this); CheckTxids alone doesn't have enough information to do so.
+func (s *Sorceror) BuildGrabTx(sm StateMsg) (*wire.MsgTx, error) {
+	_, script, err := s.grabWitness(sm)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx()
+	tx.Version = 1
+
+	prevOut := wire.NewOutPoint(&sm.Txid, sm.OutIdx)
+	txin := wire.NewTxIn(prevOut, nil)
+	tx.AddTxIn(txin)
+
+	outScript := append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 0x14},
+		sm.DestPKHScript[:]...)
+	outScript = append(outScript, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	tx.AddTxOut(wire.NewTxOut(sm.Amt, outScript))
+
+	// witness for the revocation path: <sig> <OP_1> <script>
+	r := new(big.Int).SetBytes(sm.Sig[:32])
+	ss := new(big.Int).SetBytes(sm.Sig[32:])
+	derSig := append((&btcec.Signature{R: r, S: ss}).Serialize(), byte(txscript.SigHashAll))
+
+	witness := make([][]byte, 3)
+	witness[0] = derSig
+	witness[1] = []byte{1}
+	witness[2] = script
+	tx.TxIn[0].Witness = witness
+
+	return tx, nil
+}
+
+// Watch reads merkle-checked blocks off blockChan, checks every txid in
+// each one against the Sorceror's DB, builds a grab tx for any hit, and
+// pushes it with pushTx.  It's meant to run for the life of the node,
+// fed by whatever already does merkle-checking of incoming blocks.
+func (s *Sorceror) Watch(blockChan <-chan *wire.MsgBlock, pushTx func(*wire.MsgTx) error) {
+	for blk := range blockChan {
+		txids := make([]wire.ShaHash, len(blk.Transactions))
+		txByHash := make(map[wire.ShaHash]*wire.MsgTx, len(blk.Transactions))
+		for i, tx := range blk.Transactions {
+			h := tx.TxSha()
+			txids[i] = h
+			txByHash[h] = tx
+		}
+
+		hits, err := s.CheckTxids(txids)
+		if err != nil {
+			continue
+		}
+
+		// a txid[:8] key can come back as more than one candidate when
+		// two different channels collide on those 8 bytes; group them
+		// back up by their full txid so each real match only gets
+		// grabbed once.
+		byTxid := make(map[wire.ShaHash][]StateMsg)
+		for _, sm := range hits {
+			byTxid[sm.Txid] = append(byTxid[sm.Txid], sm)
+		}
+
+		for txid, candidates := range byTxid {
+			tx, ok := txByHash[txid]
+			if !ok || len(tx.TxOut) == 0 {
+				continue
+			}
+
+			for _, sm := range candidates {
+				pub, script, err := s.grabWitness(sm)
+				if err != nil {
+					continue
+				}
+
+				// The breached to-local output isn't necessarily output 0,
+				// so try every output in the tx; sigVerifies tells us which
+				// one (if any) the stored signature actually matches.
+				var grabTx *wire.MsgTx
+				for outIdx, txOut := range tx.TxOut {
+					sm.OutIdx = uint32(outIdx)
+					sm.Amt = txOut.Value
+
+					candTx, err := s.BuildGrabTx(sm)
+					if err != nil {
+						continue
+					}
+					if sigVerifies(candTx, sm.Amt, script, sm.Sig, pub) {
+						grabTx = candTx
+						break
+					}
+				}
+				if grabTx == nil {
+					continue // not the real match; try the next candidate
+				}
+
+				_ = pushTx(grabTx)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package sorceror
+
+import (
+	"testing"
+
+	"github.com/roasbeef/btcd/btcec"
+	"github.com/roasbeef/btcd/txscript"
+	"github.com/roasbeef/btcd/wire"
+)
+
+// buildSig produces the [64]byte R||S signature format StateMsg.Sig and
+// sigVerifies both expect, for grabTx's single input spending script with
+// amt, under priv.
+func buildSig(t *testing.T, priv *btcec.PrivateKey, grabTx *wire.MsgTx, amt int64, script []byte) [64]byte {
+	hashes := txscript.NewTxSigHashes(grabTx)
+	sigHash, err := txscript.CalcWitnessSigHash(script, hashes, txscript.SigHashAll, grabTx, 0, amt)
+	if err != nil {
+		t.Fatalf("CalcWitnessSigHash: %s", err.Error())
+	}
+	sig, err := priv.Sign(sigHash)
+	if err != nil {
+		t.Fatalf("Sign: %s", err.Error())
+	}
+	var out [64]byte
+	rb := sig.R.Bytes()
+	sb := sig.S.Bytes()
+	copy(out[32-len(rb):32], rb)
+	copy(out[64-len(sb):64], sb)
+	return out
+}
+
+func dummyGrabTx() *wire.MsgTx {
+	tx := wire.NewMsgTx()
+	tx.Version = 1
+	var txid wire.ShaHash
+	txid[0] = 0x42
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txid, 0), nil))
+	tx.AddTxOut(wire.NewTxOut(50000, []byte{txscript.OP_TRUE}))
+	return tx
+}
+
+func TestSigVerifies(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %s", err.Error())
+	}
+	script := []byte{txscript.OP_TRUE}
+	amt := int64(100000)
+	tx := dummyGrabTx()
+
+	sig := buildSig(t, priv, tx, amt, script)
+	if !sigVerifies(tx, amt, script, sig, priv.PubKey()) {
+		t.Fatalf("sigVerifies: expected valid signature to verify")
+	}
+
+	// wrong amount - this is exactly the txid[:8]-collision case: a
+	// candidate's signature was made for a different amount/output, so it
+	// must not verify against this one.
+	if sigVerifies(tx, amt+1, script, sig, priv.PubKey()) {
+		t.Fatalf("sigVerifies: signature for amt %d should not verify against amt %d", amt, amt+1)
+	}
+
+	// wrong pubkey - a different channel's collision candidate.
+	other, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %s", err.Error())
+	}
+	if sigVerifies(tx, amt, script, sig, other.PubKey()) {
+		t.Fatalf("sigVerifies: signature should not verify against an unrelated pubkey")
+	}
+
+	// garbage sig
+	var garbage [64]byte
+	garbage[0] = 0xff
+	garbage[32] = 0xff
+	if sigVerifies(tx, amt, script, garbage, priv.PubKey()) {
+		t.Fatalf("sigVerifies: garbage signature should not verify")
+	}
+}